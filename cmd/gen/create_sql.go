@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"go/types"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+var createSQLTemplate = template.Must(template.New("CreateSQL").Parse(`
+{{- if .JSON}}
+import "smithsolutions/go-api/internal/util"
+{{end}}
+func (c {{.Type}}) SQL() ([]string, []any, error) {
+	columns := []string{
+		{{- range .Required}}
+		"{{.}}",
+		{{- end}}
+	}
+	params := []any{
+		{{- range .Required}}
+		c.{{.}},
+		{{- end}}
+	}
+{{range .Optional}}
+	if c.{{.}} != nil {
+		columns = append(columns, "{{.}}")
+		params = append(params, &c.{{.}})
+	}
+{{end}}
+{{- range .JSON}}
+	{{- if .Nillable}}
+	if c.{{.Name}} != nil {
+		{{.Var}}, err := util.JSONParam(c.{{.Name}})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		columns = append(columns, "{{.Name}}")
+		params = append(params, {{.Var}})
+	}
+	{{- else}}
+	{{.Var}}, err := util.JSONParam(c.{{.Name}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns = append(columns, "{{.Name}}")
+	params = append(params, {{.Var}})
+	{{- end}}
+{{end}}
+	return columns, params, nil
+}
+`))
+
+type jsonField struct {
+	Name string
+	// local variable holding the marshalled value
+	Var string
+	// nil values are left out so the column default applies
+	Nillable bool
+}
+
+// createSQL generates the Creater SQL method of typeName from its exported fields. Pointer
+// fields are only inserted when set so the column default applies otherwise, as are fields
+// tagged `orm:"json"`, which are marshalled with util.JSONParam.
+func createSQL(typeName string, structType *types.Struct) (string, error) {
+	data := struct {
+		Type     string
+		Required []string
+		Optional []string
+		JSON     []jsonField
+	}{Type: typeName}
+
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tag := reflect.StructTag(structType.Tag(i))
+
+		if !field.Exported() || strings.Contains(tag.Get("orm"), "ignore") {
+			continue
+		}
+
+		if strings.Contains(tag.Get("orm"), "json") {
+			data.JSON = append(data.JSON, jsonField{
+				Name:     field.Name(),
+				Var:      strings.ToLower(field.Name()[:1]) + field.Name()[1:],
+				Nillable: nillable(field.Type()),
+			})
+		} else if _, ok := field.Type().(*types.Pointer); ok {
+			data.Optional = append(data.Optional, field.Name())
+		} else {
+			data.Required = append(data.Required, field.Name())
+		}
+	}
+
+	var out bytes.Buffer
+	err := createSQLTemplate.Execute(&out, data)
+
+	return out.String(), err
+}
+
+func nillable(fieldType types.Type) bool {
+	switch fieldType.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Slice, *types.Interface:
+		return true
+	}
+
+	return false
+}
@@ -1,20 +1,39 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"go/types"
 	"os"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
+// generators maps the generator names accepted in //go:generate directives to their templates
+var generators = map[string]func(typeName string, structType *types.Struct) (string, error){
+	"CreateSQL": createSQL,
+}
+
 func main() {
 	if len(os.Args) != 3 {
-		failErr(fmt.Errorf("expected exactly two argumenst: <source type>"))
+		failErr(fmt.Errorf("expected exactly two arguments: <source type> <generator>"))
 	}
 	sourceType := os.Args[1]
 
-	pkg := loadPackage("")
+	generator, ok := generators[os.Args[2]]
+	if !ok {
+		failErr(fmt.Errorf("unknown generator %s", os.Args[2]))
+	}
+
+	// go generate runs the command from the directory of the file holding the directive
+	sourceFile := os.Getenv("GOFILE")
+	if sourceFile == "" {
+		failErr(fmt.Errorf("GOFILE is not set, run gen through go generate"))
+	}
+
+	pkg := loadPackage(".")
 
 	obj := pkg.Types.Scope().Lookup(sourceType)
 	if obj == nil {
@@ -31,15 +50,24 @@ func main() {
 		failErr(fmt.Errorf("type %v is not a struct", obj))
 	}
 
-	for i := 0; i < structType.NumFields(); i++ {
-		field := structType.Field(i)
-		tagValue := structType.Tag(i)
-		fmt.Println(field.Name(), tagValue, field.Type())
-	}
+	body, err := generator(sourceType, structType)
+	failErr(err)
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by gen. DO NOT EDIT.\n\n")
+	src.WriteString("package " + pkg.Name + "\n\n")
+	src.WriteString(body)
+
+	formatted, err := format.Source(src.Bytes())
+	failErr(err)
+
+	outFile := strings.TrimSuffix(sourceFile, ".go") + "_gen.go"
+	failErr(os.WriteFile(outFile, formatted, 0o644))
 }
 
 func loadPackage(path string) *packages.Package {
-	cfg := &packages.Config{Mode: packages.NeedTypes | packages.NeedImports}
+	// type check from source rather than export data, which may be newer than x/tools can read
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedSyntax | packages.NeedDeps}
 	pkgs, err := packages.Load(cfg, path)
 	if err != nil {
 		failErr(fmt.Errorf("loading packages for inspection: %v", err))
@@ -20,9 +20,11 @@ func RegisterHandlers(serviceMap ServiceMap) *http.ServeMux {
 		core.WriteJSON(w, 200, res)
 	})
 
-	userController := controllers.NewUserController(serviceMap.UserService)
+	userController := controllers.NewResourceController(&serviceMap.UserService.ResourceService)
+	eventController := controllers.NewResourceController(&serviceMap.EventService.ResourceService)
 
 	registerHandler(rootMux, "/users/", userController.GetMux())
+	registerHandler(rootMux, "/events/", eventController.GetMux())
 
 	return rootMux
 }
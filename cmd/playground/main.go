@@ -10,6 +10,9 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 	"github.com/lmittmann/tint"
+
+	"smithsolutions/go-api/internal/core"
+	"smithsolutions/go-api/internal/services"
 )
 
 func connectToDatabase() *sql.DB {
@@ -30,6 +33,16 @@ func bootstrap() (*http.ServeMux, *ServiceMap, *sql.DB) {
 		log.Fatal("Error loading .env file: " + err.Error())
 	}
 
+	if cursorSecret := os.Getenv("CURSOR_SECRET"); cursorSecret != "" {
+		services.SetCursorSecret([]byte(cursorSecret))
+	} else {
+		slog.Warn("CURSOR_SECRET is not set, pagination cursors will not survive restarts")
+	}
+
+	if os.Getenv("ERROR_FORMAT") == "envelope" {
+		core.SetErrorFormat(core.ErrorFormatEnvelope)
+	}
+
 	slog.Info("Connecting to database")
 	// get database
 	db := connectToDatabase()
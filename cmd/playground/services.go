@@ -1,9 +1,8 @@
 package main
 
 import (
-	"database/sql"
-
 	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
 )
 
 type ServiceMap struct {
@@ -11,7 +10,7 @@ type ServiceMap struct {
 	EventService *services.EventService
 }
 
-func BuildServiceMap(db *sql.DB) *ServiceMap {
+func BuildServiceMap(db util.Querier) *ServiceMap {
 	// services
 	userService := services.NewUserService(db, nil)
 	eventService := services.NewEventService(db, userService)
@@ -0,0 +1,11 @@
+CREATE TABLE
+    `event_attendees` (
+        eventId INT NOT NULL,
+        userId INT NOT NULL,
+        -- 
+        createdAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        -- 
+        PRIMARY KEY (eventId, userId),
+        FOREIGN KEY (eventId) REFERENCES events (id) ON DELETE CASCADE,
+        FOREIGN KEY (userId) REFERENCES users (id) ON DELETE CASCADE
+    );
@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+ADD settings JSON AFTER `passwordHash`;
+
+ALTER TABLE `events`
+ADD metadata JSON AFTER `coverPhotoPath`;
@@ -2,14 +2,16 @@ module smithsolutions/go-api
 
 go 1.23.0
 
-require golang.org/x/tools v0.36.0
+require (
+	github.com/go-sql-driver/mysql v1.9.2
+	github.com/joho/godotenv v1.5.1
+	github.com/lmittmann/tint v1.1.1
+	golang.org/x/tools v0.36.0
+)
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/go-sql-driver/mysql v1.9.2 // indirect
 	github.com/jmoiron/sqlx v1.4.0 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/lmittmann/tint v1.1.1 // indirect
 	golang.org/x/mod v0.27.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 )
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+const (
+	// page size used when a request does not pass a limit
+	defaultLimit = 25
+	// larger limits are rejected so a single request cannot read a whole table
+	maxLimit = 100
+)
+
+// QueryMany is the filter and pagination input of a list endpoint
+type QueryMany[whereT services.Wherer] struct {
+	Where   whereT
+	Options services.QueryOptions
+}
+
+func parseQueryMany[whereT services.Wherer](w http.ResponseWriter, r *http.Request) (QueryMany[whereT], error) {
+	var query QueryMany[whereT]
+
+	options, err := parseQueryOptions(r)
+	if err != nil {
+		return query, err
+	}
+
+	where, err := parseWhere[whereT](w, r)
+	if err != nil {
+		return query, err
+	}
+
+	query.Where = where
+	query.Options = options
+
+	return query, nil
+}
+
+// parseQueryOptions reads ?orderBy=email:desc,id&limit=20&offset=40 into query options.
+// Pages hold defaultLimit rows unless a limit between 1 and maxLimit is given.
+// Passing cursor (empty for the first page) switches to keyset pagination, which leaves out
+// the total unless withTotal=true is passed since counting costs a scan of every matching row.
+func parseQueryOptions(r *http.Request) (services.QueryOptions, error) {
+	query := r.URL.Query()
+	options := services.QueryOptions{
+		Limit:     util.ToPointer(defaultLimit),
+		WithTotal: true,
+	}
+
+	if orderBy := query.Get("orderBy"); orderBy != "" {
+		for _, term := range strings.Split(orderBy, ",") {
+			column, direction, _ := strings.Cut(term, ":")
+
+			switch strings.ToLower(direction) {
+			case "", "asc":
+				options.OrderBy = append(options.OrderBy, services.OrderBy{Column: column, Direction: services.SortAscending})
+			case "desc":
+				options.OrderBy = append(options.OrderBy, services.OrderBy{Column: column, Direction: services.SortDescending})
+			default:
+				return options, errors.New("invalid sort direction " + direction + " for " + column)
+			}
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxLimit {
+			return options, errors.New("limit must be an integer between 1 and " + strconv.Itoa(maxLimit))
+		}
+		options.Limit = &value
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return options, errors.New("offset must be a non-negative integer")
+		}
+		options.Offset = &value
+	}
+
+	if query.Has("cursor") {
+		options.UseCursor = true
+		options.WithTotal = false
+		if cursor := query.Get("cursor"); cursor != "" {
+			options.After = &cursor
+		}
+	}
+
+	if withTotal := query.Get("withTotal"); withTotal != "" {
+		value, err := strconv.ParseBool(withTotal)
+		if err != nil {
+			return options, errors.New("withTotal must be true or false")
+		}
+		options.WithTotal = value
+	}
+
+	return options, nil
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestParseQueryOptions(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  services.QueryOptions
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  services.QueryOptions{Limit: util.ToPointer(defaultLimit), WithTotal: true},
+		},
+		{
+			name:  "order by",
+			query: "orderBy=email:desc,id",
+			want: services.QueryOptions{
+				OrderBy: []services.OrderBy{
+					{Column: "email", Direction: services.SortDescending},
+					{Column: "id", Direction: services.SortAscending},
+				},
+				Limit:     util.ToPointer(defaultLimit),
+				WithTotal: true,
+			},
+		},
+		{
+			name:  "directions ignore case",
+			query: "orderBy=email:DESC,id:Asc",
+			want: services.QueryOptions{
+				OrderBy: []services.OrderBy{
+					{Column: "email", Direction: services.SortDescending},
+					{Column: "id", Direction: services.SortAscending},
+				},
+				Limit:     util.ToPointer(defaultLimit),
+				WithTotal: true,
+			},
+		},
+		{
+			name:  "limit and offset",
+			query: "limit=10&offset=40",
+			want:  services.QueryOptions{Limit: util.ToPointer(10), Offset: util.ToPointer(40), WithTotal: true},
+		},
+		{
+			name:  "maximum limit",
+			query: "limit=100",
+			want:  services.QueryOptions{Limit: util.ToPointer(maxLimit), WithTotal: true},
+		},
+		{
+			name:  "first cursor page leaves out the total",
+			query: "cursor=",
+			want:  services.QueryOptions{Limit: util.ToPointer(defaultLimit), UseCursor: true},
+		},
+		{
+			name:  "cursor page with total",
+			query: "cursor=abc&withTotal=true",
+			want:  services.QueryOptions{Limit: util.ToPointer(defaultLimit), UseCursor: true, After: util.ToPointer("abc"), WithTotal: true},
+		},
+		{
+			name:  "offset page without total",
+			query: "withTotal=false",
+			want:  services.QueryOptions{Limit: util.ToPointer(defaultLimit)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options, err := parseQueryOptions(httptest.NewRequest("GET", "/?"+c.query, nil))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(options, c.want) {
+				t.Errorf("got %+v, want %+v", options, c.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryOptionsErrors(t *testing.T) {
+	cases := []struct {
+		query string
+		err   string
+	}{
+		{"orderBy=email:up", "invalid sort direction up for email"},
+		{"limit=0", "limit must be an integer between 1 and 100"},
+		{"limit=101", "limit must be an integer between 1 and 100"},
+		{"limit=-5", "limit must be an integer between 1 and 100"},
+		{"limit=ten", "limit must be an integer between 1 and 100"},
+		{"offset=-1", "offset must be a non-negative integer"},
+		{"offset=1.5", "offset must be a non-negative integer"},
+		{"withTotal=maybe", "withTotal must be true or false"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			_, err := parseQueryOptions(httptest.NewRequest("GET", "/?"+c.query, nil))
+
+			if err == nil || err.Error() != c.err {
+				t.Errorf("got error %v, want %q", err, c.err)
+			}
+		})
+	}
+}
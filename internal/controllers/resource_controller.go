@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"smithsolutions/go-api/internal/core"
+	"smithsolutions/go-api/internal/services"
+)
+
+// request bodies larger than this are rejected before decoding
+const maxBodyBytes = 1 << 20
+
+// ResourceController exposes the CRUD operations of a ResourceService over HTTP:
+//
+//	GET    /        list rows, see parseQueryMany
+//	GET    /{id}    get one row
+//	POST   /        create a row from a createT JSON body
+//	PUT    /{id}    replace every updateT field, all of them must be given
+//	PATCH  /{id}    update only the updateT fields given in the body
+//	DELETE /{id}    delete one row
+type ResourceController[modelT any, createT services.Creater, updateT services.Updater, whereT services.Wherer, includeT any] struct {
+	mux     *http.ServeMux
+	service *services.ResourceService[modelT, createT, updateT, whereT, includeT]
+}
+
+func NewResourceController[modelT any, createT services.Creater, updateT services.Updater, whereT services.Wherer, includeT any](service *services.ResourceService[modelT, createT, updateT, whereT, includeT]) *ResourceController[modelT, createT, updateT, whereT, includeT] {
+	mux := http.NewServeMux()
+
+	controller := &ResourceController[modelT, createT, updateT, whereT, includeT]{
+		mux:     mux,
+		service: service,
+	}
+
+	controller.setupEndpoints()
+
+	return controller
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) setupEndpoints() {
+	c.mux.HandleFunc("GET /{$}", c.GetMany)
+	c.mux.HandleFunc("GET /{id}", c.GetOne)
+	c.mux.HandleFunc("POST /{$}", c.Create)
+	c.mux.HandleFunc("PUT /{id}", c.Replace)
+	c.mux.HandleFunc("PATCH /{id}", c.Update)
+	c.mux.HandleFunc("DELETE /{id}", c.Delete)
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) GetMux() *http.ServeMux {
+	return c.mux
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) GetMany(w http.ResponseWriter, r *http.Request) {
+	query, err := parseQueryMany[whereT](w, r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	rows, pageInfo, err := c.service.GetManyWithOptionsContext(r.Context(), query.Where, nil, query.Options)
+
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	response := core.Response{
+		Data:     rows,
+		Metadata: pageInfo,
+	}
+	core.WriteJSON(w, http.StatusOK, response)
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) GetOne(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	row, err := c.service.GetOneByIdContext(r.Context(), id, nil)
+
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	core.WriteJSON(w, http.StatusOK, core.Response{Data: row})
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) Create(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(w, r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	var data createT
+	if err := decodeJSONBody(body, &data); err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	row, err := c.service.CreateAndGetContext(r.Context(), data, nil)
+
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	core.WriteJSON(w, http.StatusCreated, core.Response{Data: row})
+}
+
+// Replace sets every updateT field, fields given as null are set to NULL
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) Replace(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	body, err := readBody(w, r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	if err := requireAllFields[updateT](body); err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	var data updateT
+	if err := decodeJSONBody(body, &data); err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	c.update(w, r, id, data, nil)
+}
+
+// Update only writes the updateT fields named in the body, fields given as null are set to
+// NULL and the rest keep their stored values. The row is not read first, so concurrent updates
+// to different fields do not overwrite each other.
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	body, err := readBody(w, r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	var data updateT
+	if err := decodeJSONBody(body, &data); err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	fields, _, err := bodyFields[updateT](body)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	if len(fields) == 0 {
+		core.WriteError(w, r, core.BadRequest(errors.New("PATCH requires at least one field")))
+		return
+	}
+
+	c.update(w, r, id, data, fields)
+}
+
+// update writes the named fields of data, or every field when fields is nil
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) update(w http.ResponseWriter, r *http.Request, id int, data updateT, fields []string) {
+	var err error
+	if fields == nil {
+		_, err = c.service.UpdateOneContext(r.Context(), id, data)
+	} else {
+		_, err = c.service.UpdateOneFieldsContext(r.Context(), id, data, fields)
+	}
+
+	// rows affected is 0 for updates that change nothing, so existence is checked by reading the row back
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	row, err := c.service.GetOneByIdContext(r.Context(), id, nil)
+
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	core.WriteJSON(w, http.StatusOK, core.Response{Data: row})
+}
+
+func (c *ResourceController[modelT, createT, updateT, whereT, includeT]) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+
+	if err != nil {
+		core.WriteError(w, r, core.BadRequest(err))
+		return
+	}
+
+	rowsAffected, err := c.service.DeleteOneByIdContext(r.Context(), id)
+
+	if err != nil {
+		core.WriteError(w, r, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		core.WriteError(w, r, &services.Error{Kind: services.ErrNotFound, Message: "not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathId(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, errors.New("id must be an integer")
+	}
+	return id, nil
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, errors.New("could not read request body: " + err.Error())
+	}
+	return body, nil
+}
+
+func decodeJSONBody(body []byte, dest any) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dest); err != nil {
+		return errors.New("invalid JSON body: " + strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	if decoder.More() {
+		return errors.New("invalid JSON body: unexpected data after the object")
+	}
+
+	return nil
+}
+
+// requireAllFields checks that a JSON object names every field of T
+func requireAllFields[T any](body []byte) error {
+	_, missing, err := bodyFields[T](body)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return errors.New("PUT requires every field, missing " + strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// bodyFields splits the fields of T into those named by the keys of a JSON object and the lower
+// camel case names of those missing from it, keys match field names regardless of case like
+// encoding/json
+func bodyFields[T any](body []byte) ([]string, []string, error) {
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, nil, errors.New("invalid JSON body: " + strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	present := []string{}
+	missing := []string{}
+	rType := reflect.TypeFor[T]()
+
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		found := false
+		for key := range keys {
+			if strings.EqualFold(key, field.Name) {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			present = append(present, field.Name)
+		} else {
+			missing = append(missing, strings.ToLower(field.Name[:1])+field.Name[1:])
+		}
+	}
+
+	return present, missing, nil
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"smithsolutions/go-api/internal/core"
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+// thing and its input types back a controller over a "things" table, so the generic controller
+// is tested apart from the user and event models
+type thing struct {
+	Id   int
+	Name string
+	Note *string
+}
+
+type createThing struct {
+	Name string
+	Note *string
+}
+
+func (c createThing) SQL() ([]string, []any, error) {
+	return util.GetCreateSQL(c)
+}
+
+type updateThing struct {
+	Name *string `validate:"required"`
+	Note *string
+}
+
+func (u updateThing) SQL() (string, []any, error) {
+	return util.GetUpdateSQL(u)
+}
+
+type whereThing struct {
+	Id   *filters.IntFilter
+	Name *filters.StringFilter
+}
+
+func (w whereThing) SQL() (string, []any, error) {
+	return util.GetWhereSQL(w)
+}
+
+// insertResult is the result of an INSERT that created row 5
+type insertResult struct{}
+
+func (insertResult) LastInsertId() (int64, error) {
+	return 5, nil
+}
+
+func (insertResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+// newThingServer mounts a thing controller under /things/ the way the playground mounts its
+// controllers, every SELECT is answered with rows
+func newThingServer(t *testing.T, rows ...[]driver.Value) (http.Handler, *stubdb.DB) {
+	db, stub := stubdb.Open(t)
+	stub.Query = func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT COUNT(*)") {
+			return []string{"COUNT(*)"}, [][]driver.Value{{int64(len(rows))}}, nil
+		}
+		return []string{"id", "name", "note"}, rows, nil
+	}
+	stub.Exec = func(query string, args []driver.Value) (driver.Result, error) {
+		if strings.HasPrefix(query, "INSERT") {
+			return insertResult{}, nil
+		}
+		return driver.RowsAffected(len(rows)), nil
+	}
+
+	service := services.SetupResourceService[thing, createThing, updateThing, whereThing, struct{}](db, "things", &thing{})
+	controller := NewResourceController[thing, createThing, updateThing, whereThing, struct{}](&service)
+
+	mux := http.NewServeMux()
+	mux.Handle("/things/", http.StripPrefix("/things", controller.GetMux()))
+
+	return mux, stub
+}
+
+// thingResponse holds either a response envelope or the problem details of an error
+type thingResponse struct {
+	Data     json.RawMessage
+	Metadata *services.PageInfo
+
+	Detail string
+	Code   string
+	Errors []core.FieldError
+}
+
+func serve(t *testing.T, handler http.Handler, method string, target string, body string) (*httptest.ResponseRecorder, thingResponse) {
+	var request *http.Request
+	if body == "" {
+		request = httptest.NewRequest(method, target, nil)
+	} else {
+		request = httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	var response thingResponse
+	if contentType := recorder.Header().Get("Content-Type"); contentType == "application/json" || contentType == core.ProblemContentType {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response body %q: %v", recorder.Body.String(), err)
+		}
+	}
+
+	return recorder, response
+}
+
+func checkStatements(t *testing.T, stub *stubdb.DB, want []stubdb.Statement) {
+	t.Helper()
+
+	statements := stub.Statements()
+	if len(statements) != len(want) {
+		t.Fatalf("statements = %q, want %d statements", stub.Log(), len(want))
+	}
+
+	for i, statement := range statements {
+		if statement.Query != want[i].Query || !slices.Equal(statement.Args, want[i].Args) {
+			t.Errorf("statement %d = %q %v, want %q %v", i, statement.Query, statement.Args, want[i].Query, want[i].Args)
+		}
+	}
+}
+
+func TestResourceControllerGetMany(t *testing.T) {
+	server, stub := newThingServer(t, []driver.Value{int64(1), "a", nil}, []driver.Value{int64(2), "b", "note"})
+
+	recorder, response := serve(t, server, "GET", "/things/?name[in]=a,b&orderBy=name:desc&limit=10", "")
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	checkStatements(t, stub, []stubdb.Statement{
+		{Query: "SELECT COUNT(*) FROM things WHERE name IN (?, ?)", Args: []driver.Value{"a", "b"}},
+		{Query: "SELECT id, name, note FROM things WHERE name IN (?, ?) ORDER BY name DESC LIMIT 10", Args: []driver.Value{"a", "b"}},
+	})
+
+	var rows []thing
+	if err := json.Unmarshal(response.Data, &rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []thing{{Id: 1, Name: "a"}, {Id: 2, Name: "b", Note: util.ToPointer("note")}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+
+	if response.Metadata == nil || response.Metadata.Total == nil || *response.Metadata.Total != 2 {
+		t.Errorf("metadata = %+v", response.Metadata)
+	}
+}
+
+func TestResourceControllerGetOne(t *testing.T) {
+	server, stub := newThingServer(t, []driver.Value{int64(4), "a", nil})
+
+	recorder, response := serve(t, server, "GET", "/things/4", "")
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	checkStatements(t, stub, []stubdb.Statement{
+		{Query: "SELECT id, name, note FROM things WHERE id=? LIMIT 1", Args: []driver.Value{int64(4)}},
+	})
+
+	if want := `{"Id":4,"Name":"a","Note":null}`; string(response.Data) != want {
+		t.Errorf("data = %s, want %s", response.Data, want)
+	}
+}
+
+func TestResourceControllerCreate(t *testing.T) {
+	server, stub := newThingServer(t, []driver.Value{int64(5), "a", "note"})
+
+	recorder, response := serve(t, server, "POST", "/things/", `{"name": "a", "note": "note"}`)
+
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	checkStatements(t, stub, []stubdb.Statement{
+		{Query: "INSERT INTO things (name,note) VALUES (?, ?)", Args: []driver.Value{"a", "note"}},
+		{Query: "SELECT id, name, note FROM things WHERE id=? LIMIT 1", Args: []driver.Value{int64(5)}},
+	})
+
+	if want := `{"Id":5,"Name":"a","Note":"note"}`; string(response.Data) != want {
+		t.Errorf("data = %s, want %s", response.Data, want)
+	}
+}
+
+func TestResourceControllerReplace(t *testing.T) {
+	server, stub := newThingServer(t, []driver.Value{int64(4), "b", nil})
+
+	recorder, _ := serve(t, server, "PUT", "/things/4", `{"name": "b", "note": null}`)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	// every field is written, the null note clears its column
+	checkStatements(t, stub, []stubdb.Statement{
+		{Query: "UPDATE things SET name=?, note=? WHERE id=? LIMIT 1", Args: []driver.Value{"b", nil, int64(4)}},
+		{Query: "SELECT id, name, note FROM things WHERE id=? LIMIT 1", Args: []driver.Value{int64(4)}},
+	})
+}
+
+func TestResourceControllerUpdate(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want stubdb.Statement
+	}{
+		{"given field only", `{"name": "b"}`, stubdb.Statement{Query: "UPDATE things SET name=? WHERE id=? LIMIT 1", Args: []driver.Value{"b", int64(4)}}},
+		{"null clears the column", `{"Note": null}`, stubdb.Statement{Query: "UPDATE things SET note=? WHERE id=? LIMIT 1", Args: []driver.Value{nil, int64(4)}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, stub := newThingServer(t, []driver.Value{int64(4), "b", nil})
+
+			recorder, _ := serve(t, server, "PATCH", "/things/4", c.body)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+			}
+
+			// the row is not read before the update
+			checkStatements(t, stub, []stubdb.Statement{
+				c.want,
+				{Query: "SELECT id, name, note FROM things WHERE id=? LIMIT 1", Args: []driver.Value{int64(4)}},
+			})
+		})
+	}
+}
+
+func TestResourceControllerDelete(t *testing.T) {
+	server, stub := newThingServer(t, []driver.Value{int64(4), "a", nil})
+
+	recorder, _ := serve(t, server, "DELETE", "/things/4", "")
+
+	if recorder.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	checkStatements(t, stub, []stubdb.Statement{
+		{Query: "DELETE FROM things WHERE id =? LIMIT 1", Args: []driver.Value{int64(4)}},
+	})
+}
+
+func TestResourceControllerErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+		err    string
+	}{
+		{"id is not an integer", "GET", "/things/abc", "", http.StatusBadRequest, "bad_request", "id must be an integer"},
+		{"invalid query", "GET", "/things/?limit=0", "", http.StatusBadRequest, "bad_request", "limit must be an integer between 1 and 100"},
+		{"unknown create field", "POST", "/things/", `{"name": "a", "color": "red"}`, http.StatusBadRequest, "bad_request", `invalid JSON body: unknown field "color"`},
+		{"trailing data", "POST", "/things/", `{"name": "a"} {}`, http.StatusBadRequest, "bad_request", "invalid JSON body: unexpected data after the object"},
+		{"put missing a field", "PUT", "/things/4", `{"name": "a"}`, http.StatusBadRequest, "bad_request", "PUT requires every field, missing note"},
+		{"empty patch", "PATCH", "/things/4", `{}`, http.StatusBadRequest, "bad_request", "PATCH requires at least one field"},
+		{"put clearing a required field", "PUT", "/things/4", `{"name": null, "note": null}`, http.StatusBadRequest, "validation_failed", "name is required"},
+		{"patch clearing a required field", "PATCH", "/things/4", `{"name": null}`, http.StatusBadRequest, "validation_failed", "name is required"},
+		{"unknown order by column", "GET", "/things/?orderBy=color", "", http.StatusBadRequest, "validation_failed", "cannot order by unknown column color"},
+		{"get missing row", "GET", "/things/4", "", http.StatusNotFound, "not_found", "not found"},
+		{"patch missing row", "PATCH", "/things/4", `{"name": "a"}`, http.StatusNotFound, "not_found", "not found"},
+		{"delete missing row", "DELETE", "/things/4", "", http.StatusNotFound, "not_found", "not found"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// no rows exist
+			server, _ := newThingServer(t)
+
+			recorder, response := serve(t, server, c.method, c.target, c.body)
+
+			if recorder.Code != c.status || response.Code != c.code || response.Detail != c.err {
+				t.Errorf("got %d %s %q, want %d %s %q", recorder.Code, response.Code, response.Detail, c.status, c.code, c.err)
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != core.ProblemContentType {
+				t.Errorf("content type = %q, want %q", contentType, core.ProblemContentType)
+			}
+		})
+	}
+}
+
+func TestResourceControllerWriteErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		err    error
+		status int
+		code   string
+		errors []core.FieldError
+	}{
+		{"duplicate create", "POST", "/things/", `{"name": "a", "note": null}`, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'things.name'"}, http.StatusConflict, "conflict", []core.FieldError{{Field: "name", Message: "a row with the same name already exists"}}},
+		{"duplicate update", "PATCH", "/things/4", `{"name": "a"}`, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'things.name'"}, http.StatusConflict, "conflict", []core.FieldError{{Field: "name", Message: "a row with the same name already exists"}}},
+		{"referenced delete", "DELETE", "/things/4", "", &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails"}, http.StatusConflict, "foreign_key_violation", nil},
+		{"driver failure", "DELETE", "/things/4", "", errors.New("connection refused"), http.StatusInternalServerError, "internal_error", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server, stub := newThingServer(t, []driver.Value{int64(4), "a", nil})
+			stub.Exec = func(query string, args []driver.Value) (driver.Result, error) {
+				return nil, c.err
+			}
+
+			recorder, response := serve(t, server, c.method, c.target, c.body)
+
+			if recorder.Code != c.status || response.Code != c.code {
+				t.Errorf("got %d %s %q, want %d %s", recorder.Code, response.Code, response.Detail, c.status, c.code)
+			}
+
+			if !reflect.DeepEqual(response.Errors, c.errors) {
+				t.Errorf("errors = %+v, want %+v", response.Errors, c.errors)
+			}
+		})
+	}
+}
+
+func TestResourceControllerRoutes(t *testing.T) {
+	cases := []struct {
+		method string
+		target string
+		status int
+	}{
+		{"GET", "/things/", http.StatusOK},
+		{"GET", "/things/4/notes", http.StatusNotFound},
+		{"POST", "/things/4", http.StatusMethodNotAllowed},
+		{"PUT", "/things/", http.StatusMethodNotAllowed},
+		{"PATCH", "/things/", http.StatusMethodNotAllowed},
+		{"DELETE", "/things/", http.StatusMethodNotAllowed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method+" "+c.target, func(t *testing.T) {
+			server, _ := newThingServer(t)
+
+			recorder, _ := serve(t, server, c.method, c.target, "")
+
+			if recorder.Code != c.status {
+				t.Errorf("status = %d, want %d", recorder.Code, c.status)
+			}
+		})
+	}
+}
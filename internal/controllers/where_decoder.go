@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+// query parameters that are read by parseQueryOptions rather than the where decoder
+var reservedQueryParams = []string{"orderBy", "limit", "offset", "cursor", "where"}
+
+// short operator names accepted alongside the filter field names
+var operatorAliases = map[string]string{
+	"eq":  "Equals",
+	"ne":  "IsNot",
+	"lt":  "LessThan",
+	"lte": "LessThanOrEqualTo",
+	"gt":  "GreaterThan",
+	"gte": "GreaterThanOrEqualTo",
+	"nin": "NotIn",
+}
+
+// parseWhere reads a where struct from the request. Filters can be given as query parameters
+// such as ?email[contains]=foo&id[gt]=5&or[0][id][eq]=1, or as JSON either in the where
+// query parameter or as a JSON request body:
+//
+//	{"email": {"contains": "foo"}, "id": {"gt": 5}, "or": [{"id": 1}]}
+//
+// Field and operator names are the lower camel case names of the struct fields, and a plain
+// value is shorthand for equals. Lists in query parameters are comma separated.
+func parseWhere[whereT services.Wherer](w http.ResponseWriter, r *http.Request) (whereT, error) {
+	var where whereT
+
+	input, err := whereInputFromRequest(w, r)
+	if err != nil || input == nil {
+		return where, err
+	}
+
+	if err := decodeWhereStruct(reflect.ValueOf(&where).Elem(), input, "where"); err != nil {
+		return where, err
+	}
+
+	// surface conflicting or empty filters as bad requests rather than query failures
+	if _, _, err := where.SQL(); err != nil {
+		return where, err
+	}
+
+	return where, nil
+}
+
+func whereInputFromRequest(w http.ResponseWriter, r *http.Request) (whereInput, error) {
+	var inputs []whereInput
+
+	query := r.URL.Query()
+
+	if query.Has("where") {
+		inputs = append(inputs, jsonInput(query.Get("where")))
+	}
+
+	if r.Body != nil && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		body, err := readBody(w, r)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			inputs = append(inputs, jsonInput(body))
+		}
+	}
+
+	params, err := parseQueryWhere(query)
+	if err != nil {
+		return nil, err
+	}
+	if params != nil {
+		inputs = append(inputs, params)
+	}
+
+	if len(inputs) > 1 {
+		return nil, errors.New("where can only be given once, either as query parameters, the where parameter or a JSON body")
+	}
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+
+	return inputs[0], nil
+}
+
+// whereInput is a node of the request's where tree, either decoded JSON or bracketed query
+// parameters
+type whereInput interface {
+	object(path string) (map[string]whereInput, error)
+	list(path string) ([]whereInput, error)
+	// isObject reports whether the node holds named children rather than a value
+	isObject() bool
+	decode(target reflect.Value, path string) error
+}
+
+func decodeWhereStruct(target reflect.Value, input whereInput, path string) error {
+	fields, err := input.object(path)
+	if err != nil {
+		return err
+	}
+
+	isFilter := reflect.PointerTo(target.Type()).Implements(reflect.TypeFor[util.FilterSQLer]())
+
+	for _, key := range sortedKeys(fields) {
+		fieldPath := path + "." + key
+
+		field, ok := lookupWhereField(target, key, isFilter)
+		if !ok {
+			if isFilter {
+				return errors.New("unknown operator " + key + " for " + path)
+			}
+			return errors.New("unknown field " + fieldPath)
+		}
+
+		if err := decodeWhereValue(field, fields[key], fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeWhereValue(field reflect.Value, input whereInput, path string) error {
+	fieldType := field.Type()
+
+	switch {
+	case fieldType.Kind() == reflect.Pointer && isWhereNode(fieldType.Elem()):
+		value := reflect.New(fieldType.Elem())
+		if err := decodeWhereNode(value.Elem(), input, path); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	case fieldType.Kind() == reflect.Pointer && fieldType.Elem().Kind() == reflect.Slice && isWhereNodeList(fieldType.Elem().Elem()):
+		members, err := input.list(path)
+		if err != nil {
+			return err
+		}
+
+		memberType := fieldType.Elem().Elem()
+		slice := reflect.MakeSlice(fieldType.Elem(), 0, len(members))
+
+		for i, member := range members {
+			memberPath := path + "[" + strconv.Itoa(i) + "]"
+
+			var value reflect.Value
+			if memberType.Kind() == reflect.Pointer {
+				value = reflect.New(memberType.Elem())
+				if err := decodeWhereNode(value.Elem(), member, memberPath); err != nil {
+					return err
+				}
+			} else {
+				value = reflect.New(memberType).Elem()
+				if err := decodeWhereNode(value, member, memberPath); err != nil {
+					return err
+				}
+			}
+
+			slice = reflect.Append(slice, value)
+		}
+
+		pointer := reflect.New(slice.Type())
+		pointer.Elem().Set(slice)
+		field.Set(pointer)
+		return nil
+	}
+
+	return input.decode(field, path)
+}
+
+// decodeWhereNode decodes a where, relation filter or filter struct, a plain value given for a
+// filter is shorthand for its Equals operator
+func decodeWhereNode(target reflect.Value, input whereInput, path string) error {
+	isFilter := reflect.PointerTo(target.Type()).Implements(reflect.TypeFor[util.FilterSQLer]())
+
+	if isFilter && !input.isObject() {
+		equals := target.FieldByName("Equals")
+		if !equals.IsValid() {
+			return errors.New(path + " must be an object of operators")
+		}
+		return decodeWhereValue(equals, input, path)
+	}
+
+	return decodeWhereStruct(target, input, path)
+}
+
+// isWhereNode reports whether values of t are decoded field by field rather than as a value
+func isWhereNode(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	pointer := reflect.PointerTo(t)
+	return pointer.Implements(reflect.TypeFor[util.FilterSQLer]()) ||
+		pointer.Implements(reflect.TypeFor[util.RelationFilterSQLer]()) ||
+		pointer.Implements(reflect.TypeFor[services.Wherer]())
+}
+
+func isWhereNodeList(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return isWhereNode(t)
+}
+
+func lookupWhereField(target reflect.Value, key string, isFilter bool) (reflect.Value, bool) {
+	name, isAlias := operatorAliases[key]
+	if !isFilter || !isAlias {
+		first, size := utf8.DecodeRuneInString(key)
+		if !unicode.IsLower(first) {
+			return reflect.Value{}, false
+		}
+		name = string(unicode.ToUpper(first)) + key[size:]
+	}
+
+	field, ok := target.Type().FieldByName(name)
+	if !ok || len(field.Index) != 1 || strings.Contains(field.Tag.Get("orm"), "ignore") {
+		return reflect.Value{}, false
+	}
+
+	return target.FieldByIndex(field.Index), true
+}
+
+func sortedKeys[valueT any](values map[string]valueT) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return "JSON value"
+	}
+	return t.String()
+}
+
+// jsonInput is a where tree read from JSON
+type jsonInput json.RawMessage
+
+func (j jsonInput) trimmed() []byte {
+	return bytes.TrimSpace(j)
+}
+
+func (j jsonInput) isObject() bool {
+	return bytes.HasPrefix(j.trimmed(), []byte("{"))
+}
+
+func (j jsonInput) object(path string) (map[string]whereInput, error) {
+	raw := map[string]json.RawMessage{}
+	if !j.isObject() || json.Unmarshal(j, &raw) != nil {
+		return nil, errors.New(path + " must be a JSON object")
+	}
+
+	fields := map[string]whereInput{}
+	for key, value := range raw {
+		fields[key] = jsonInput(value)
+	}
+	return fields, nil
+}
+
+func (j jsonInput) list(path string) ([]whereInput, error) {
+	raw := []json.RawMessage{}
+	if json.Unmarshal(j, &raw) != nil {
+		return nil, errors.New(path + " must be a JSON array")
+	}
+
+	members := []whereInput{}
+	for _, value := range raw {
+		members = append(members, jsonInput(value))
+	}
+	return members, nil
+}
+
+func (j jsonInput) decode(target reflect.Value, path string) error {
+	value := reflect.New(target.Type())
+
+	if err := json.Unmarshal(j, value.Interface()); err != nil {
+		// exact decimals such as big.Rat only decode from strings, accept plain numbers too
+		unmarshaler, ok := reflect.New(target.Type().Elem()).Interface().(encoding.TextUnmarshaler)
+		if target.Kind() != reflect.Pointer || !ok || unmarshaler.UnmarshalText(j.trimmed()) != nil {
+			return errors.New("invalid value for " + path + ", expected " + describeType(target.Type()))
+		}
+		value.Elem().Set(reflect.ValueOf(unmarshaler))
+	}
+
+	target.Set(value.Elem())
+	return nil
+}
+
+// queryInput is a where tree read from bracketed query parameters
+type queryInput struct {
+	children map[string]*queryInput
+	value    *string
+}
+
+// parseQueryWhere builds a where tree from every query parameter that is not reserved,
+// returning nil when there are none
+func parseQueryWhere(query url.Values) (*queryInput, error) {
+	var root *queryInput
+
+	for _, key := range sortedKeys(query) {
+		if slices.Contains(reservedQueryParams, key) {
+			continue
+		}
+
+		segments, err := splitQueryKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(query[key]) > 1 {
+			return nil, errors.New("query parameter " + key + " is given more than once, separate list values with commas")
+		}
+
+		if root == nil {
+			root = &queryInput{children: map[string]*queryInput{}}
+		}
+
+		node := root
+		for i, segment := range segments {
+			if node.value != nil {
+				return nil, errors.New("query parameter " + key + " conflicts with a value given for where." + strings.Join(segments[:i], "."))
+			}
+			if node.children == nil {
+				node.children = map[string]*queryInput{}
+			}
+
+			child, ok := node.children[segment]
+			if !ok {
+				child = &queryInput{}
+				node.children[segment] = child
+			}
+			node = child
+		}
+
+		if node.children != nil {
+			return nil, errors.New("query parameter " + key + " conflicts with operators given for the same field")
+		}
+
+		value := query.Get(key)
+		node.value = &value
+	}
+
+	return root, nil
+}
+
+// splitQueryKey splits email[contains] into email and contains
+func splitQueryKey(key string) ([]string, error) {
+	invalid := errors.New("invalid query parameter " + key)
+
+	name, rest, hasBrackets := strings.Cut(key, "[")
+	if name == "" || strings.Contains(name, "]") {
+		return nil, invalid
+	}
+
+	segments := []string{name}
+	if !hasBrackets {
+		return segments, nil
+	}
+
+	rest = "[" + rest
+	for rest != "" {
+		end := strings.Index(rest, "]")
+		if !strings.HasPrefix(rest, "[") || end < 2 || strings.Contains(rest[1:end], "[") {
+			return nil, invalid
+		}
+
+		segments = append(segments, rest[1:end])
+		rest = rest[end+1:]
+	}
+
+	return segments, nil
+}
+
+func (q *queryInput) isObject() bool {
+	return q.children != nil
+}
+
+func (q *queryInput) object(path string) (map[string]whereInput, error) {
+	if q.children == nil {
+		return nil, errors.New(path + " must be given as " + path + "[...] parameters")
+	}
+
+	fields := map[string]whereInput{}
+	for key, child := range q.children {
+		fields[key] = child
+	}
+	return fields, nil
+}
+
+// list reads or[0][...]&or[1][...] style parameters in index order
+func (q *queryInput) list(path string) ([]whereInput, error) {
+	if q.children == nil {
+		return nil, errors.New(path + " must be given as " + path + "[0][...] parameters")
+	}
+
+	indexes := []int{}
+	for key := range q.children {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 {
+			return nil, errors.New(path + " has an invalid index " + key)
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	members := []whereInput{}
+	for _, index := range indexes {
+		members = append(members, q.children[strconv.Itoa(index)])
+	}
+	return members, nil
+}
+
+func (q *queryInput) decode(target reflect.Value, path string) error {
+	if q.value == nil {
+		return errors.New(path + " must be a single value")
+	}
+
+	if err := decodeQueryValue(target, *q.value); err != nil {
+		return errors.New("invalid value for " + path + ", expected " + describeType(target.Type()))
+	}
+	return nil
+}
+
+func decodeQueryValue(target reflect.Value, value string) error {
+	if target.Kind() == reflect.Pointer {
+		element := reflect.New(target.Type().Elem())
+		if err := decodeQueryValue(element.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(element)
+		return nil
+	}
+
+	if unmarshaler, ok := target.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		target.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(parsed)
+	case reflect.Slice:
+		parts := []string{}
+		if value != "" {
+			parts = strings.Split(value, ",")
+		}
+
+		slice := reflect.MakeSlice(target.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := decodeQueryValue(slice.Index(i), part); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.Array:
+		parts := strings.Split(value, ",")
+		if len(parts) != target.Len() {
+			return errors.New("wrong number of values")
+		}
+
+		for i, part := range parts {
+			if err := decodeQueryValue(target.Index(i), part); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		// JSON filter values keep their JSON type when they parse as JSON, otherwise they are strings
+		var parsed any
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			parsed = value
+		}
+		target.Set(reflect.ValueOf(parsed))
+	default:
+		return errors.New("unsupported type")
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+func init() {
+	// registers the relation filters of the where structs
+	userService := services.NewUserService(nil, nil)
+	userService.SetEventService(services.NewEventService(nil, userService))
+}
+
+func TestParseWhere(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		body  string
+		want  services.WhereUser
+	}{
+		{
+			name: "no input",
+			want: services.WhereUser{},
+		},
+		{
+			name:  "plain value is equals",
+			query: "email=a@example.com",
+			want:  services.WhereUser{Email: &filters.StringFilter{Equals: util.ToPointer("a@example.com")}},
+		},
+		{
+			name:  "operator field name",
+			query: "email[contains]=foo&email[caseInsensitive]=true",
+			want:  services.WhereUser{Email: &filters.StringFilter{Contains: util.ToPointer("foo"), CaseInsensitive: true}},
+		},
+		{
+			name:  "operator aliases",
+			query: "id[gte]=1&id[lt]=5&id[ne]=3&id[nin]=2,4",
+			want: services.WhereUser{Id: &filters.IntFilter{
+				GreaterThanOrEqualTo: util.ToPointer(1),
+				LessThan:             util.ToPointer(5),
+				IsNot:                util.ToPointer(3),
+				NotIn:                &[]int{2, 4},
+			}},
+		},
+		{
+			name:  "comma separated list",
+			query: "id[in]=1,2,3",
+			want:  services.WhereUser{Id: &filters.IntFilter{In: &[]int{1, 2, 3}}},
+		},
+		{
+			name:  "bracketed or list in index order",
+			query: "or[1][email]=b&or[0][id][eq]=1",
+			want: services.WhereUser{Or: &[]services.WhereUser{
+				{Id: &filters.IntFilter{Equals: util.ToPointer(1)}},
+				{Email: &filters.StringFilter{Equals: util.ToPointer("b")}},
+			}},
+		},
+		{
+			name:  "not",
+			query: "not[id]=1",
+			want:  services.WhereUser{Not: &services.WhereUser{Id: &filters.IntFilter{Equals: util.ToPointer(1)}}},
+		},
+		{
+			name:  "relation filter",
+			query: "events[some][label][startsWith]=x",
+			want: services.WhereUser{Events: &filters.RelationFilter[services.WhereEvent]{
+				Some: &services.WhereEvent{Label: &filters.StringFilter{StartsWith: util.ToPointer("x")}},
+			}},
+		},
+		{
+			name:  "reserved parameters are skipped",
+			query: "limit=5&offset=10&orderBy=id&cursor=&email=a",
+			want:  services.WhereUser{Email: &filters.StringFilter{Equals: util.ToPointer("a")}},
+		},
+		{
+			name:  "where parameter",
+			query: "where=" + url.QueryEscape(`{"email": {"contains": "foo"}, "or": [{"id": 1}, {"id": {"gt": 5}}]}`),
+			want: services.WhereUser{
+				Email: &filters.StringFilter{Contains: util.ToPointer("foo")},
+				Or: &[]services.WhereUser{
+					{Id: &filters.IntFilter{Equals: util.ToPointer(1)}},
+					{Id: &filters.IntFilter{GreaterThan: util.ToPointer(5)}},
+				},
+			},
+		},
+		{
+			name: "JSON body",
+			body: `{"id": {"in": [1, 2]}, "email": "a"}`,
+			want: services.WhereUser{
+				Id:    &filters.IntFilter{In: &[]int{1, 2}},
+				Email: &filters.StringFilter{Equals: util.ToPointer("a")},
+			},
+		},
+		{
+			name:  "JSON body with reserved parameters",
+			query: "limit=5",
+			body:  `{"id": 1}`,
+			want:  services.WhereUser{Id: &filters.IntFilter{Equals: util.ToPointer(1)}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			where, err := parseWhere[services.WhereUser](httptest.NewRecorder(), newWhereRequest(c.query, c.body))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(where, c.want) {
+				got, _ := json.Marshal(where)
+				want, _ := json.Marshal(c.want)
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		body  string
+		err   string
+	}{
+		{"unknown field", "foo=1", "", "unknown field where.foo"},
+		{"unknown nested field", "or[0][foo]=1", "", "unknown field where.or[0].foo"},
+		{"unknown operator", "email[bogus]=x", "", "unknown operator bogus for where.email"},
+		{"alias is not a field", "eq=1", "", "unknown field where.eq"},
+		{"alias of another type", "email[gt]=a", "", "unknown operator gt for where.email"},
+		{"unknown JSON field", "where=" + url.QueryEscape(`{"foo": 1}`), "", "unknown field where.foo"},
+		{"unknown JSON operator", "", `{"id": {"bogus": 1}}`, "unknown operator bogus for where.id"},
+		{"invalid value", "id[gt]=x", "", "invalid value for where.id.gt, expected int"},
+		{"invalid list member", "id[in]=1,x", "", "invalid value for where.id.in, expected []int"},
+		{"invalid JSON value", "", `{"id": {"gt": "x"}}`, "invalid value for where.id.gt, expected int"},
+		{"unclosed bracket", "email[contains=x", "", "invalid query parameter email[contains"},
+		{"empty brackets", "email[]=x", "", "invalid query parameter email[]"},
+		{"missing name", "[email]=x", "", "invalid query parameter [email]"},
+		{"text after brackets", "email[contains]x=1", "", "invalid query parameter email[contains]x"},
+		{"repeated parameter", "email=a&email=b", "", "query parameter email is given more than once, separate list values with commas"},
+		{"value and operators", "email=a&email[contains]=b", "", "query parameter email[contains] conflicts with a value given for where.email"},
+		{"invalid list index", "or[x][id]=1", "", "where.or has an invalid index x"},
+		{"list given as value", "or=1", "", "where.or must be given as where.or[0][...] parameters"},
+		{"where parameter and query parameters", "where=" + url.QueryEscape(`{"id": 1}`) + "&email=a", "", "where can only be given once, either as query parameters, the where parameter or a JSON body"},
+		{"where parameter and body", "where=" + url.QueryEscape(`{"id": 1}`), `{"id": 1}`, "where can only be given once, either as query parameters, the where parameter or a JSON body"},
+		{"body and query parameters", "email=a", `{"id": 1}`, "where can only be given once, either as query parameters, the where parameter or a JSON body"},
+		{"where parameter not an object", "where=" + url.QueryEscape(`[1]`), "", "where must be a JSON object"},
+		{"body too large", "", `{"email": "` + strings.Repeat("a", maxBodyBytes) + `"}`, "could not read request body: http: request body too large"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseWhere[services.WhereUser](httptest.NewRecorder(), newWhereRequest(c.query, c.body))
+
+			if err == nil {
+				t.Fatalf("expected error %q", c.err)
+			}
+
+			if err.Error() != c.err {
+				t.Errorf("error = %q, want %q", err.Error(), c.err)
+			}
+		})
+	}
+}
+
+func newWhereRequest(query string, body string) *http.Request {
+	if body == "" {
+		return httptest.NewRequest("GET", "/?"+query, nil)
+	}
+
+	r := httptest.NewRequest("GET", "/?"+query, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
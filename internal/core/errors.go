@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+// error codes sent in Response.Code and Problem.Code, clients may rely on these staying the same
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidation         = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeForeignKey         = "foreign_key_violation"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeInternal           = "internal_error"
+)
+
+// Error is an error that carries the status and code it is written with
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// BadRequest wraps an error caused by a malformed request
+func BadRequest(err error) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    CodeBadRequest,
+		Message: err.Error(),
+	}
+}
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{services.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{services.ErrConflict, http.StatusConflict, CodeConflict},
+	{services.ErrValidation, http.StatusBadRequest, CodeValidation},
+	{services.ErrForeignKey, http.StatusConflict, CodeForeignKey},
+	{services.ErrServiceFailed, http.StatusServiceUnavailable, CodeServiceUnavailable},
+}
+
+// ErrorStatus returns the HTTP status and error code for a typed error, anything else is an
+// internal error
+func ErrorStatus(err error) (int, string) {
+	var coreErr *Error
+	if errors.As(err, &coreErr) {
+		return coreErr.Status, coreErr.Code
+	}
+
+	for _, errorStatus := range errorStatuses {
+		if errors.Is(err, errorStatus.kind) {
+			return errorStatus.status, errorStatus.code
+		}
+	}
+
+	return http.StatusInternalServerError, CodeInternal
+}
+
+// WriteError writes err with the status and code from ErrorStatus in the configured error
+// format. Internal errors are logged and replaced with a generic message so database details
+// never reach the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status, _ := ErrorStatus(err)
+	writeError(w, r, status, err)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, err error) {
+	problem := ProblemFor(statusCode, err)
+
+	if problem.Status >= http.StatusInternalServerError {
+		slog.Error("request failed", "status", problem.Status, "err", err)
+	}
+
+	if r != nil {
+		problem.Instance = r.URL.Path
+	}
+
+	if GetErrorFormat() == ErrorFormatEnvelope {
+		writeJSON(w, problem.Status, "application/json", &Response{
+			Error: problem.Detail,
+			Code:  problem.Code,
+		})
+		return
+	}
+
+	writeJSON(w, problem.Status, ProblemContentType, problem)
+}
+
+// ProblemFor describes err as problem details. Typed errors use their own status, other
+// errors use statusCode and have their message hidden when it is a server error.
+func ProblemFor(statusCode int, err error) Problem {
+	status, code := ErrorStatus(err)
+	if code == CodeInternal {
+		status = statusCode
+		code = codeForStatus(statusCode)
+	}
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+		Code:   code,
+	}
+
+	if status >= http.StatusInternalServerError {
+		problem.Detail = http.StatusText(status)
+	}
+
+	var coreErr *Error
+	var validationErrs util.ValidationErrors
+	var serviceErr *services.Error
+
+	if errors.As(err, &coreErr) {
+		problem.Errors = coreErr.Fields
+	} else if errors.As(err, &validationErrs) {
+		for _, validationErr := range validationErrs {
+			problem.Errors = append(problem.Errors, FieldError{Field: validationErr.Field, Message: validationErr.Message})
+		}
+	} else if errors.As(err, &serviceErr) && serviceErr.Field != "" {
+		problem.Errors = []FieldError{{Field: serviceErr.Field, Message: serviceErr.Message}}
+	}
+
+	return problem
+}
+
+func codeForStatus(statusCode int) string {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return CodeNotFound
+	case statusCode == http.StatusConflict:
+		return CodeConflict
+	case statusCode == http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case statusCode >= http.StatusInternalServerError:
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
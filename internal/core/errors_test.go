@@ -0,0 +1,176 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/services"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", &services.Error{Kind: services.ErrNotFound}, http.StatusNotFound, "not_found"},
+		{"conflict", &services.Error{Kind: services.ErrConflict}, http.StatusConflict, "conflict"},
+		{"validation", &services.Error{Kind: services.ErrValidation}, http.StatusBadRequest, "validation_failed"},
+		{"foreign key", &services.Error{Kind: services.ErrForeignKey}, http.StatusConflict, "foreign_key_violation"},
+		{"service failed", services.ErrServiceFailed, http.StatusServiceUnavailable, "service_unavailable"},
+		{"wrapped kind", fmt.Errorf("loading: %w", &services.Error{Kind: services.ErrNotFound}), http.StatusNotFound, "not_found"},
+		{"bad request", BadRequest(errors.New("id must be an integer")), http.StatusBadRequest, "bad_request"},
+		{"core error", &Error{Status: http.StatusTeapot, Code: "teapot"}, http.StatusTeapot, "teapot"},
+		{"bad request", BadRequest(errors.New("id must be an integer")), http.StatusBadRequest, "bad_request"},
+		{"core error", &Error{Status: http.StatusTeapot, Code: "teapot"}, http.StatusTeapot, "teapot"},
+		{"untyped", errors.New("connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, code := ErrorStatus(c.err)
+
+			if status != c.status || code != c.code {
+				t.Errorf("got %d %s, want %d %s", status, code, c.status, c.code)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	validationErrs := util.ValidationErrors{
+		{Field: "email", Message: "email must be a valid email address"},
+		{Field: "passwordHash", Message: "passwordHash is required"},
+	}
+
+	cases := []struct {
+		name    string
+		err     error
+		problem Problem
+	}{
+		{
+			name: "bad request",
+			err:  BadRequest(errors.New("id must be an integer")),
+			problem: Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest,
+				Detail: "id must be an integer", Instance: "/users/x", Code: CodeBadRequest,
+			},
+		},
+		{
+			name: "validation errors",
+			err:  &services.Error{Kind: services.ErrValidation, Message: validationErrs.Error(), Err: validationErrs},
+			problem: Problem{
+				Type: "about:blank", Title: "Bad Request", Status: http.StatusBadRequest,
+				Detail: validationErrs.Error(), Instance: "/users/x", Code: CodeValidation,
+				Errors: []FieldError{
+					{Field: "email", Message: "email must be a valid email address"},
+					{Field: "passwordHash", Message: "passwordHash is required"},
+				},
+			},
+		},
+		{
+			name: "service error field",
+			err:  &services.Error{Kind: services.ErrConflict, Field: "email", Message: "a row with the same email already exists"},
+			problem: Problem{
+				Type: "about:blank", Title: "Conflict", Status: http.StatusConflict,
+				Detail: "a row with the same email already exists", Instance: "/users/x", Code: CodeConflict,
+				Errors: []FieldError{{Field: "email", Message: "a row with the same email already exists"}},
+			},
+		},
+		{
+			name: "internal errors hide their detail",
+			err:  errors.New("dial tcp 10.0.0.1:3306: connection refused"),
+			problem: Problem{
+				Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "Internal Server Error", Instance: "/users/x", Code: CodeInternal,
+			},
+		},
+		{
+			name: "service unavailable hides its detail",
+			err:  services.ErrServiceFailed,
+			problem: Problem{
+				Type: "about:blank", Title: "Service Unavailable", Status: http.StatusServiceUnavailable,
+				Detail: "Service Unavailable", Instance: "/users/x", Code: CodeServiceUnavailable,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, httptest.NewRequest("GET", "/users/x", nil), c.err)
+
+			if w.Code != c.problem.Status {
+				t.Errorf("status = %d, want %d", w.Code, c.problem.Status)
+			}
+
+			if contentType := w.Header().Get("Content-Type"); contentType != ProblemContentType {
+				t.Errorf("content type = %q, want %q", contentType, ProblemContentType)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid body %s: %v", w.Body.String(), err)
+			}
+
+			if !reflect.DeepEqual(problem, c.problem) {
+				t.Errorf("got %+v, want %+v", problem, c.problem)
+			}
+		})
+	}
+}
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	SetErrorFormat(ErrorFormatEnvelope)
+	t.Cleanup(func() { SetErrorFormat(ErrorFormatProblem) })
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"typed error", &services.Error{Kind: services.ErrNotFound, Message: "not found"}, http.StatusNotFound, `{"Data":null,"Error":"not found","Code":"not_found","Metadata":null}`},
+		{"internal error", errors.New("secret connection string"), http.StatusInternalServerError, `{"Data":null,"Error":"Internal Server Error","Code":"internal_error","Metadata":null}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteError(w, httptest.NewRequest("GET", "/users/1", nil), c.err)
+
+			if w.Code != c.status {
+				t.Errorf("status = %d, want %d", w.Code, c.status)
+			}
+
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("content type = %q, want application/json", contentType)
+			}
+
+			if body := strings.TrimSpace(w.Body.String()); body != c.body {
+				t.Errorf("body = %s, want %s", body, c.body)
+			}
+		})
+	}
+}
+
+func TestWriteJSONWritesErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteJSON(w, http.StatusBadGateway, errors.New("upstream failed"))
+
+	if w.Code != http.StatusBadGateway || w.Header().Get("Content-Type") != ProblemContentType {
+		t.Errorf("got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if strings.Contains(w.Body.String(), "upstream failed") {
+		t.Errorf("expected the detail to be hidden, got %s", w.Body.String())
+	}
+}
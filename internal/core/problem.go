@@ -0,0 +1,44 @@
+package core
+
+// ErrorFormat selects how errors are written to clients
+type ErrorFormat int
+
+const (
+	// RFC 7807 problem details served as application/problem+json
+	ErrorFormatProblem ErrorFormat = iota
+	// the Response envelope with Error and Code set, for clients written before problem details
+	ErrorFormatEnvelope
+)
+
+var currentErrorFormat = ErrorFormatProblem
+
+// SetErrorFormat sets how errors are written, defaults to problem details
+func SetErrorFormat(format ErrorFormat) {
+	currentErrorFormat = format
+}
+
+func GetErrorFormat() ErrorFormat {
+	return currentErrorFormat
+}
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Type is about:blank with the status text as
+// title, clients should match on Code instead.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// extension members
+	Code   string       `json:"code"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a problem with a single field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
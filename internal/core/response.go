@@ -1,7 +1,9 @@
 package core
 
 type Response struct {
-	Data     any
-	Error    string
+	Data  any
+	Error string
+	// machine readable error code, see the Code constants
+	Code     string
 	Metadata any
 }
@@ -5,8 +5,18 @@ import (
 	"net/http"
 )
 
+// WriteJSON writes data as JSON, an error is written in the configured error format instead
 func WriteJSON(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
+	if err, ok := data.(error); ok {
+		writeError(w, nil, statusCode, err)
+		return
+	}
+
+	writeJSON(w, statusCode, "application/json", data)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, contentType string, data any) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
 }
@@ -1,8 +1,10 @@
 package filters
 
 import (
+	"errors"
 	"strconv"
-	"strings"
+
+	"smithsolutions/go-api/internal/util"
 )
 
 type BoolFilter struct {
@@ -13,48 +15,32 @@ type BoolFilter struct {
 	And    *[]*BoolFilter
 }
 
-func (f *BoolFilter) SQL(columnKey string) (string, []any) {
-
-	if f.Equals != nil {
-		return columnKey + " = ?", []any{strconv.FormatBool(*f.Equals)}
-	} else if f.IsNot != nil {
-		return columnKey + " != ?", []any{strconv.FormatBool(*f.IsNot)}
-	} else if f.IsNull != nil {
-		if *f.IsNull {
-			return columnKey + " IS NULL", []any{}
-		} else {
-			return columnKey + " IS NOT NULL", []any{}
-		}
-	} else if f.And != nil {
-
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.And {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
-			}
+func (f *BoolFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
 
-			individualParameters = append(individualParameters, parameters...)
-		}
+	b := filterSQL{}
 
-		return "(" + strings.Join(individualSQLStrings, " AND ") + ")", individualParameters
-	} else if f.Or != nil {
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.Or {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
-			}
-
-			individualParameters = append(individualParameters, parameters...)
+	if f.Equals != nil {
+		b.add(columnKey+" = ?", *f.Equals)
+	}
+	if f.IsNot != nil {
+		if f.Equals != nil && *f.Equals == *f.IsNot {
+			return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + strconv.FormatBool(*f.IsNot))
 		}
+		b.add(columnKey+" != ?", *f.IsNot)
+	}
 
-		return "(" + strings.Join(individualSQLStrings, " OR ") + ")", individualParameters
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
 	}
 
-	return "", []any{}
+	return b.build(columnKey)
 }
 
 func BoolEquals(value bool) *BoolFilter {
@@ -86,3 +72,42 @@ func BoolOr(values []*BoolFilter) *BoolFilter {
 		Or: &values,
 	}
 }
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *BoolFilter) Match(value *bool) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a bool or *bool model field using SQL three valued logic
+func (f *BoolFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[bool](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *BoolFilter) evaluate(value *bool) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compareTruth(value, func(v bool) bool { return v == *f.Equals }))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compareTruth(value, func(v bool) bool { return v != *f.IsNot }))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *BoolFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
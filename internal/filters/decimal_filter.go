@@ -0,0 +1,366 @@
+package filters
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+// mysql DECIMAL columns hold at most 30 digits after the decimal point
+const maxDecimalScale = 30
+
+// DecimalFilter compares exact decimal values such as prices without rounding them through float64
+type DecimalFilter struct {
+	Equals               *big.Rat
+	LessThan             *big.Rat
+	GreaterThan          *big.Rat
+	LessThanOrEqualTo    *big.Rat
+	GreaterThanOrEqualTo *big.Rat
+	IsNot                *big.Rat
+	In                   *[]*big.Rat
+	NotIn                *[]*big.Rat
+	Between              *[2]*big.Rat
+	NotBetween           *[2]*big.Rat
+	IsNull               *bool
+	Or                   *[]*DecimalFilter
+	And                  *[]*DecimalFilter
+}
+
+func (f *DecimalFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	b := filterSQL{}
+
+	placeholder := "?"
+	if util.GetDialect() == util.DialectMySQL {
+		// comparing a DECIMAL column with a string parameter would compare them as doubles
+		placeholder = "CAST(? AS DECIMAL(65, 30))"
+	}
+
+	compare := func(operator string, value *big.Rat) error {
+		param, err := decimalString(columnKey, value)
+		if err != nil {
+			return err
+		}
+		b.add(columnKey+" "+operator+" "+placeholder, param)
+		return nil
+	}
+
+	comparisons := []struct {
+		operator string
+		value    *big.Rat
+	}{
+		{"=", f.Equals},
+		{"<", f.LessThan},
+		{"<=", f.LessThanOrEqualTo},
+		{">", f.GreaterThan},
+		{">=", f.GreaterThanOrEqualTo},
+		{"!=", f.IsNot},
+	}
+
+	for _, comparison := range comparisons {
+		if comparison.value == nil {
+			continue
+		}
+		if err := compare(comparison.operator, comparison.value); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if f.Equals != nil && f.IsNot != nil && f.Equals.Cmp(f.IsNot) == 0 {
+		return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + f.IsNot.RatString())
+	}
+
+	for _, set := range []struct {
+		operator string
+		values   *[]*big.Rat
+	}{{"IN", f.In}, {"NOT IN", f.NotIn}} {
+		if set.values == nil {
+			continue
+		}
+
+		params := []any{}
+		for _, value := range *set.values {
+			param, err := decimalString(columnKey, value)
+			if err != nil {
+				return "", nil, err
+			}
+			params = append(params, param)
+		}
+
+		sql, params := inSQLWithPlaceholder(columnKey, set.operator, placeholder, params)
+		b.add(sql, params...)
+	}
+
+	for _, rangeFilter := range []struct {
+		operator string
+		bounds   *[2]*big.Rat
+	}{{"BETWEEN", f.Between}, {"NOT BETWEEN", f.NotBetween}} {
+		if rangeFilter.bounds == nil {
+			continue
+		}
+
+		start, err := decimalString(columnKey, rangeFilter.bounds[0])
+		if err != nil {
+			return "", nil, err
+		}
+		end, err := decimalString(columnKey, rangeFilter.bounds[1])
+		if err != nil {
+			return "", nil, err
+		}
+		if rangeFilter.bounds[0].Cmp(rangeFilter.bounds[1]) > 0 {
+			return "", nil, errors.New("filter on " + columnKey + " has a range that starts after it ends")
+		}
+
+		b.add(columnKey+" "+rangeFilter.operator+" "+placeholder+" AND "+placeholder, start, end)
+	}
+
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+// decimalString formats value with exactly as many decimal places as it needs, values such
+// as 1/3 that have no finite decimal representation are rejected
+func decimalString(columnKey string, value *big.Rat) (string, error) {
+	if value == nil {
+		return "", errors.New("filter on " + columnKey + " has a nil decimal value")
+	}
+
+	denominator := new(big.Int).Set(value.Denom())
+	twos, fives := 0, 0
+	two, five := big.NewInt(2), big.NewInt(5)
+	remainder := new(big.Int)
+
+	for {
+		quotient, mod := new(big.Int).QuoRem(denominator, two, remainder)
+		if mod.Sign() != 0 {
+			break
+		}
+		denominator = quotient
+		twos++
+	}
+	for {
+		quotient, mod := new(big.Int).QuoRem(denominator, five, remainder)
+		if mod.Sign() != 0 {
+			break
+		}
+		denominator = quotient
+		fives++
+	}
+
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		return "", errors.New("filter on " + columnKey + " has a value with no exact decimal representation " + value.RatString())
+	}
+
+	scale := max(twos, fives)
+	if scale > maxDecimalScale {
+		return "", errors.New("filter on " + columnKey + " has a value with more than 30 decimal places")
+	}
+
+	return value.FloatString(scale), nil
+}
+
+// ParseDecimal parses a decimal string such as "19.99" into an exact value
+func ParseDecimal(value string) (*big.Rat, error) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, errors.New("invalid decimal " + value)
+	}
+	return rat, nil
+}
+
+func DecimalEquals(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		Equals: value,
+	}
+}
+
+func DecimalLessThan(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		LessThan: value,
+	}
+}
+
+func DecimalLessThanOrEqualTo(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		LessThanOrEqualTo: value,
+	}
+}
+
+func DecimalGreaterThan(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		GreaterThan: value,
+	}
+}
+
+func DecimalGreaterThanOrEqualTo(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		GreaterThanOrEqualTo: value,
+	}
+}
+
+func DecimalIsNot(value *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		IsNot: value,
+	}
+}
+
+func DecimalIn(values []*big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		In: &values,
+	}
+}
+
+func DecimalNotIn(values []*big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		NotIn: &values,
+	}
+}
+
+func DecimalBetween(min *big.Rat, max *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		Between: &[2]*big.Rat{min, max},
+	}
+}
+
+func DecimalNotBetween(min *big.Rat, max *big.Rat) *DecimalFilter {
+	return &DecimalFilter{
+		NotBetween: &[2]*big.Rat{min, max},
+	}
+}
+
+func DecimalIsNull(value bool) *DecimalFilter {
+	return &DecimalFilter{
+		IsNull: &value,
+	}
+}
+
+func DecimalAnd(values []*DecimalFilter) *DecimalFilter {
+	return &DecimalFilter{
+		And: &values,
+	}
+}
+
+func DecimalOr(values []*DecimalFilter) *DecimalFilter {
+	return &DecimalFilter{
+		Or: &values,
+	}
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *DecimalFilter) Match(value *big.Rat) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a *big.Rat or decimal string model field using SQL three
+// valued logic
+func (f *DecimalFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	var v *big.Rat
+
+	switch typed := value.(type) {
+	case string:
+		rat, err := ParseDecimal(typed)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		v = rat
+	case *string:
+		if typed != nil {
+			rat, err := ParseDecimal(*typed)
+			if err != nil {
+				return util.TruthUnknown, err
+			}
+			v = rat
+		}
+	default:
+		rat, err := nullableValue[big.Rat](value)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		v = rat
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *DecimalFilter) evaluate(value *big.Rat) (util.Truth, error) {
+	compare := func(predicate func(cmp int) bool, other *big.Rat) util.Truth {
+		if value == nil {
+			return util.TruthUnknown
+		}
+		return util.TruthOf(predicate(value.Cmp(other)))
+	}
+
+	contains := func(values []*big.Rat) bool {
+		return slices.ContainsFunc(values, func(other *big.Rat) bool { return value.Cmp(other) == 0 })
+	}
+
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp == 0 }, f.Equals))
+	}
+	if f.LessThan != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp < 0 }, f.LessThan))
+	}
+	if f.LessThanOrEqualTo != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp <= 0 }, f.LessThanOrEqualTo))
+	}
+	if f.GreaterThan != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp > 0 }, f.GreaterThan))
+	}
+	if f.GreaterThanOrEqualTo != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp >= 0 }, f.GreaterThanOrEqualTo))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp != 0 }, f.IsNot))
+	}
+	if f.In != nil {
+		if len(*f.In) == 0 {
+			truth = truth.And(util.TruthFalse)
+		} else if value == nil {
+			truth = truth.And(util.TruthUnknown)
+		} else {
+			truth = truth.And(util.TruthOf(contains(*f.In)))
+		}
+	}
+	if f.NotIn != nil && len(*f.NotIn) > 0 {
+		if value == nil {
+			truth = truth.And(util.TruthUnknown)
+		} else {
+			truth = truth.And(util.TruthOf(!contains(*f.NotIn)))
+		}
+	}
+	if f.Between != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp >= 0 }, f.Between[0]))
+		truth = truth.And(compare(func(cmp int) bool { return cmp <= 0 }, f.Between[1]))
+	}
+	if f.NotBetween != nil {
+		truth = truth.And(compare(func(cmp int) bool { return cmp < 0 }, f.NotBetween[0]).
+			Or(compare(func(cmp int) bool { return cmp > 0 }, f.NotBetween[1])))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *DecimalFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
@@ -0,0 +1,215 @@
+package filters
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+var (
+	enumValues      = make(map[reflect.Type][]string)
+	enumValuesMutex sync.RWMutex
+)
+
+// DeclareEnum registers the values an enum type may take, EnumFilter rejects anything else
+// before it reaches SQL
+//
+//	type EventStatus string
+//	func init() { filters.DeclareEnum(EventStatusDraft, EventStatusPublished) }
+func DeclareEnum[T ~string](values ...T) {
+	enumValuesMutex.Lock()
+	defer enumValuesMutex.Unlock()
+
+	declared := []string{}
+	for _, value := range values {
+		declared = append(declared, string(value))
+	}
+
+	enumValues[reflect.TypeFor[T]()] = declared
+}
+
+// EnumValues returns the declared values of an enum type
+func EnumValues[T ~string]() ([]T, bool) {
+	enumValuesMutex.RLock()
+	defer enumValuesMutex.RUnlock()
+
+	declared, ok := enumValues[reflect.TypeFor[T]()]
+	if !ok {
+		return nil, false
+	}
+
+	values := []T{}
+	for _, value := range declared {
+		values = append(values, T(value))
+	}
+
+	return values, true
+}
+
+type EnumFilter[T ~string] struct {
+	Equals *T
+	IsNot  *T
+	In     *[]T
+	NotIn  *[]T
+	IsNull *bool
+	Or     *[]*EnumFilter[T]
+	And    *[]*EnumFilter[T]
+}
+
+func (f *EnumFilter[T]) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	declared, ok := EnumValues[T]()
+	if !ok {
+		return "", nil, errors.New("filter on " + columnKey + " uses enum " + reflect.TypeFor[T]().String() + " which has not been declared")
+	}
+
+	validate := func(values ...T) ([]any, error) {
+		params := []any{}
+		for _, value := range values {
+			if !slices.Contains(declared, value) {
+				return nil, errors.New("filter on " + columnKey + " has unknown " + reflect.TypeFor[T]().Name() + " value " + string(value))
+			}
+			params = append(params, string(value))
+		}
+		return params, nil
+	}
+
+	b := filterSQL{}
+
+	if f.Equals != nil {
+		params, err := validate(*f.Equals)
+		if err != nil {
+			return "", nil, err
+		}
+		b.add(columnKey+" = ?", params...)
+	}
+	if f.IsNot != nil {
+		if f.Equals != nil && *f.Equals == *f.IsNot {
+			return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + string(*f.IsNot))
+		}
+		params, err := validate(*f.IsNot)
+		if err != nil {
+			return "", nil, err
+		}
+		b.add(columnKey+" != ?", params...)
+	}
+	if f.In != nil {
+		params, err := validate(*f.In...)
+		if err != nil {
+			return "", nil, err
+		}
+		sql, params := inSQL(columnKey, "IN", params)
+		b.add(sql, params...)
+	}
+	if f.NotIn != nil {
+		params, err := validate(*f.NotIn...)
+		if err != nil {
+			return "", nil, err
+		}
+		sql, params := inSQL(columnKey, "NOT IN", params)
+		b.add(sql, params...)
+	}
+
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+func EnumEquals[T ~string](value T) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		Equals: &value,
+	}
+}
+
+func EnumIsNot[T ~string](value T) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		IsNot: &value,
+	}
+}
+
+func EnumIn[T ~string](values []T) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		In: &values,
+	}
+}
+
+func EnumNotIn[T ~string](values []T) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		NotIn: &values,
+	}
+}
+
+func EnumIsNull[T ~string](value bool) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		IsNull: &value,
+	}
+}
+
+func EnumAnd[T ~string](values []*EnumFilter[T]) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		And: &values,
+	}
+}
+
+func EnumOr[T ~string](values []*EnumFilter[T]) *EnumFilter[T] {
+	return &EnumFilter[T]{
+		Or: &values,
+	}
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *EnumFilter[T]) Match(value *T) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a T or *T model field using SQL three valued logic
+func (f *EnumFilter[T]) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[T](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *EnumFilter[T]) evaluate(value *T) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compareTruth(value, func(v T) bool { return v == *f.Equals }))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compareTruth(value, func(v T) bool { return v != *f.IsNot }))
+	}
+	if f.In != nil {
+		truth = truth.And(inTruth(value, *f.In))
+	}
+	if f.NotIn != nil {
+		truth = truth.And(notInTruth(value, *f.NotIn))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *EnumFilter[T]) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
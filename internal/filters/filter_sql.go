@@ -0,0 +1,83 @@
+package filters
+
+import (
+	"errors"
+	"strings"
+)
+
+type filterSQLer interface {
+	SQL(columnKey string) (string, []any, error)
+}
+
+// filterSQL collects the conditions of a filter, every set operator has to match
+type filterSQL struct {
+	conditions []string
+	params     []any
+	isNull     bool
+}
+
+func (b *filterSQL) add(condition string, params ...any) {
+	b.conditions = append(b.conditions, condition)
+	b.params = append(b.params, params...)
+}
+
+func (b *filterSQL) addIsNull(columnKey string, isNull *bool) {
+	if isNull == nil {
+		return
+	}
+
+	if *isNull {
+		// checked in build once the And and Or groups are added as well
+		b.isNull = true
+		b.add(columnKey + " IS NULL")
+	} else {
+		b.add(columnKey + " IS NOT NULL")
+	}
+}
+
+func addGroup[filterT filterSQLer](b *filterSQL, columnKey string, name string, filters *[]filterT) error {
+	if filters == nil {
+		return nil
+	}
+
+	if len(*filters) == 0 {
+		return errors.New("filter on " + columnKey + " has an empty " + name + " list")
+	}
+
+	separator := " AND "
+	if name == "Or" {
+		separator = " OR "
+	}
+
+	individualSQLStrings := []string{}
+	individualParameters := []any{}
+	for _, filter := range *filters {
+		sqlStr, parameters, err := filter.SQL(columnKey)
+		if err != nil {
+			return err
+		}
+
+		individualSQLStrings = append(individualSQLStrings, sqlStr)
+		individualParameters = append(individualParameters, parameters...)
+	}
+
+	b.add("("+strings.Join(individualSQLStrings, separator)+")", individualParameters...)
+
+	return nil
+}
+
+func (b *filterSQL) build(columnKey string) (string, []any, error) {
+	if len(b.conditions) == 0 {
+		return "", nil, errors.New("filter on " + columnKey + " has no operators set")
+	}
+
+	if b.isNull && len(b.conditions) > 1 {
+		return "", nil, errors.New("filter on " + columnKey + " cannot combine IsNull with value comparisons")
+	}
+
+	if len(b.conditions) == 1 {
+		return b.conditions[0], b.params, nil
+	}
+
+	return "(" + strings.Join(b.conditions, " AND ") + ")", b.params, nil
+}
@@ -0,0 +1,257 @@
+package filters
+
+import (
+	"cmp"
+	"errors"
+	"strconv"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type FloatFilter struct {
+	Equals               *float64
+	LessThan             *float64
+	GreaterThan          *float64
+	LessThanOrEqualTo    *float64
+	GreaterThanOrEqualTo *float64
+	IsNot                *float64
+	In                   *[]float64
+	NotIn                *[]float64
+	Between              *[2]float64
+	NotBetween           *[2]float64
+	IsNull               *bool
+	Or                   *[]*FloatFilter
+	And                  *[]*FloatFilter
+}
+
+func (f *FloatFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	b := filterSQL{}
+
+	if f.Equals != nil {
+		b.add(columnKey+" = ?", *f.Equals)
+	}
+	if f.LessThan != nil {
+		b.add(columnKey+" < ?", *f.LessThan)
+	}
+	if f.LessThanOrEqualTo != nil {
+		b.add(columnKey+" <= ?", *f.LessThanOrEqualTo)
+	}
+	if f.GreaterThan != nil {
+		b.add(columnKey+" > ?", *f.GreaterThan)
+	}
+	if f.GreaterThanOrEqualTo != nil {
+		b.add(columnKey+" >= ?", *f.GreaterThanOrEqualTo)
+	}
+	if f.IsNot != nil {
+		if f.Equals != nil && *f.Equals == *f.IsNot {
+			return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + strconv.FormatFloat(*f.IsNot, 'g', -1, 64))
+		}
+		b.add(columnKey+" != ?", *f.IsNot)
+	}
+	if f.In != nil {
+		sql, params := inSQL(columnKey, "IN", floatParams(*f.In))
+		b.add(sql, params...)
+	}
+	if f.NotIn != nil {
+		sql, params := inSQL(columnKey, "NOT IN", floatParams(*f.NotIn))
+		b.add(sql, params...)
+	}
+	if f.Between != nil {
+		if f.Between[0] > f.Between[1] {
+			return "", nil, errors.New("filter on " + columnKey + " has a Between range that starts after it ends")
+		}
+		b.add(columnKey+" BETWEEN ? AND ?", floatParams(f.Between[:])...)
+	}
+	if f.NotBetween != nil {
+		if f.NotBetween[0] > f.NotBetween[1] {
+			return "", nil, errors.New("filter on " + columnKey + " has a NotBetween range that starts after it ends")
+		}
+		b.add(columnKey+" NOT BETWEEN ? AND ?", floatParams(f.NotBetween[:])...)
+	}
+
+	if f.comparedRange().empty() {
+		return "", nil, errors.New("filter on " + columnKey + " has comparisons that no value can match")
+	}
+
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+// comparedRange is the range of values allowed by Equals, Between and the ordering comparisons
+func (f *FloatFilter) comparedRange() *valueRange[float64] {
+	r := &valueRange[float64]{compare: cmp.Compare[float64]}
+
+	r.atLeast(f.Equals, false)
+	r.atMost(f.Equals, false)
+	r.atLeast(f.GreaterThan, true)
+	r.atLeast(f.GreaterThanOrEqualTo, false)
+	r.atMost(f.LessThan, true)
+	r.atMost(f.LessThanOrEqualTo, false)
+
+	if f.Between != nil {
+		r.atLeast(&f.Between[0], false)
+		r.atMost(&f.Between[1], false)
+	}
+
+	return r
+}
+
+func FloatEquals(value float64) *FloatFilter {
+	return &FloatFilter{
+		Equals: &value,
+	}
+}
+
+func FloatLessThan(value float64) *FloatFilter {
+	return &FloatFilter{
+		LessThan: &value,
+	}
+}
+
+func FloatLessThanOrEqualTo(value float64) *FloatFilter {
+	return &FloatFilter{
+		LessThanOrEqualTo: &value,
+	}
+}
+
+func FloatGreaterThan(value float64) *FloatFilter {
+	return &FloatFilter{
+		GreaterThan: &value,
+	}
+}
+
+func FloatGreaterThanOrEqualTo(value float64) *FloatFilter {
+	return &FloatFilter{
+		GreaterThanOrEqualTo: &value,
+	}
+}
+
+func FloatIsNot(value float64) *FloatFilter {
+	return &FloatFilter{
+		IsNot: &value,
+	}
+}
+
+func FloatIn(values []float64) *FloatFilter {
+	return &FloatFilter{
+		In: &values,
+	}
+}
+
+func FloatNotIn(values []float64) *FloatFilter {
+	return &FloatFilter{
+		NotIn: &values,
+	}
+}
+
+func FloatBetween(min float64, max float64) *FloatFilter {
+	return &FloatFilter{
+		Between: &[2]float64{min, max},
+	}
+}
+
+func FloatNotBetween(min float64, max float64) *FloatFilter {
+	return &FloatFilter{
+		NotBetween: &[2]float64{min, max},
+	}
+}
+
+func FloatIsNull(value bool) *FloatFilter {
+	return &FloatFilter{
+		IsNull: &value,
+	}
+}
+
+func FloatAnd(values []*FloatFilter) *FloatFilter {
+	return &FloatFilter{
+		And: &values,
+	}
+}
+
+func FloatOr(values []*FloatFilter) *FloatFilter {
+	return &FloatFilter{
+		Or: &values,
+	}
+}
+
+func floatParams(values []float64) []any {
+	params := []any{}
+	for _, value := range values {
+		params = append(params, value)
+	}
+	return params
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *FloatFilter) Match(value *float64) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a float64 or *float64 model field using SQL three valued logic
+func (f *FloatFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[float64](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *FloatFilter) evaluate(value *float64) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v == *f.Equals }))
+	}
+	if f.LessThan != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v < *f.LessThan }))
+	}
+	if f.LessThanOrEqualTo != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v <= *f.LessThanOrEqualTo }))
+	}
+	if f.GreaterThan != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v > *f.GreaterThan }))
+	}
+	if f.GreaterThanOrEqualTo != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v >= *f.GreaterThanOrEqualTo }))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v != *f.IsNot }))
+	}
+	if f.In != nil {
+		truth = truth.And(inTruth(value, *f.In))
+	}
+	if f.NotIn != nil {
+		truth = truth.And(notInTruth(value, *f.NotIn))
+	}
+	if f.Between != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v >= f.Between[0] && v <= f.Between[1] }))
+	}
+	if f.NotBetween != nil {
+		truth = truth.And(compareTruth(value, func(v float64) bool { return v < f.NotBetween[0] || v > f.NotBetween[1] }))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *FloatFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
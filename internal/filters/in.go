@@ -0,0 +1,24 @@
+package filters
+
+import "strings"
+
+// inSQL expands to column IN (?, ?, ...). SQL has no empty list syntax, so an empty IN never
+// matches and an empty NOT IN always matches, NULL values included.
+func inSQL(columnKey string, operator string, params []any) (string, []any) {
+	return inSQLWithPlaceholder(columnKey, operator, "?", params)
+}
+
+// inSQLWithPlaceholder is inSQL with a placeholder expression such as a CAST around each member
+func inSQLWithPlaceholder(columnKey string, operator string, placeholder string, params []any) (string, []any) {
+	if len(params) == 0 {
+		if operator == "IN" {
+			return "1 = 0", []any{}
+		}
+		return "1 = 1", []any{}
+	}
+
+	placeholders := strings.Repeat(placeholder+", ", len(params))
+	placeholders = placeholders[:len(placeholders)-2]
+
+	return columnKey + " " + operator + " (" + placeholders + ")", params
+}
@@ -1,8 +1,11 @@
 package filters
 
 import (
+	"cmp"
+	"errors"
 	"strconv"
-	"strings"
+
+	"smithsolutions/go-api/internal/util"
 )
 
 type IntFilter struct {
@@ -12,60 +15,96 @@ type IntFilter struct {
 	LessThanOrEqualTo    *int
 	GreaterThanOrEqualTo *int
 	IsNot                *int
+	In                   *[]int
+	NotIn                *[]int
+	Between              *[2]int
+	NotBetween           *[2]int
 	IsNull               *bool
 	Or                   *[]*IntFilter
 	And                  *[]*IntFilter
 }
 
-func (f *IntFilter) SQL(columnKey string) (string, []any) {
+func (f *IntFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	b := filterSQL{}
+
 	if f.Equals != nil {
-		return columnKey + " = ?", []any{strconv.Itoa(*f.Equals)}
-	} else if f.LessThan != nil {
-		return columnKey + " < ?", []any{strconv.Itoa(*f.LessThan)}
-	} else if f.LessThanOrEqualTo != nil {
-		return columnKey + " <= ?", []any{strconv.Itoa(*f.LessThanOrEqualTo)}
-	} else if f.GreaterThan != nil {
-		return columnKey + " > ?", []any{strconv.Itoa(*f.GreaterThan)}
-	} else if f.GreaterThanOrEqualTo != nil {
-		return columnKey + " >= ?", []any{strconv.Itoa(*f.GreaterThanOrEqualTo)}
-	} else if f.IsNot != nil {
-		return columnKey + " != ?", []any{strconv.Itoa(*f.IsNot)}
-	} else if f.IsNull != nil {
-		if *f.IsNull {
-			return columnKey + " IS NULL", []any{}
-		} else {
-			return columnKey + " IS NOT NULL", []any{}
+		b.add(columnKey+" = ?", strconv.Itoa(*f.Equals))
+	}
+	if f.LessThan != nil {
+		b.add(columnKey+" < ?", strconv.Itoa(*f.LessThan))
+	}
+	if f.LessThanOrEqualTo != nil {
+		b.add(columnKey+" <= ?", strconv.Itoa(*f.LessThanOrEqualTo))
+	}
+	if f.GreaterThan != nil {
+		b.add(columnKey+" > ?", strconv.Itoa(*f.GreaterThan))
+	}
+	if f.GreaterThanOrEqualTo != nil {
+		b.add(columnKey+" >= ?", strconv.Itoa(*f.GreaterThanOrEqualTo))
+	}
+	if f.IsNot != nil {
+		if f.Equals != nil && *f.Equals == *f.IsNot {
+			return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + strconv.Itoa(*f.IsNot))
 		}
-	} else if f.And != nil {
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.And {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
-			}
+		b.add(columnKey+" != ?", strconv.Itoa(*f.IsNot))
+	}
+	if f.In != nil {
+		sql, params := inSQL(columnKey, "IN", intParams(*f.In))
+		b.add(sql, params...)
+	}
+	if f.NotIn != nil {
+		sql, params := inSQL(columnKey, "NOT IN", intParams(*f.NotIn))
+		b.add(sql, params...)
+	}
+	if f.Between != nil {
+		if f.Between[0] > f.Between[1] {
+			return "", nil, errors.New("filter on " + columnKey + " has a Between range that starts after it ends")
+		}
+		b.add(columnKey+" BETWEEN ? AND ?", intParams(f.Between[:])...)
+	}
+	if f.NotBetween != nil {
+		if f.NotBetween[0] > f.NotBetween[1] {
+			return "", nil, errors.New("filter on " + columnKey + " has a NotBetween range that starts after it ends")
+		}
+		b.add(columnKey+" NOT BETWEEN ? AND ?", intParams(f.NotBetween[:])...)
+	}
+
+	if f.comparedRange().empty() {
+		return "", nil, errors.New("filter on " + columnKey + " has comparisons that no value can match")
+	}
 
-			individualParameters = append(individualParameters, parameters...)
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
 
-		}
+	return b.build(columnKey)
+}
 
-		return "(" + strings.Join(individualSQLStrings, " AND ") + ")", individualParameters
-	} else if f.Or != nil {
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.Or {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
-			}
-
-			individualParameters = append(individualParameters, parameters...)
-		}
+// comparedRange is the range of values allowed by Equals, Between and the ordering comparisons
+func (f *IntFilter) comparedRange() *valueRange[int] {
+	r := &valueRange[int]{compare: cmp.Compare[int]}
+
+	r.atLeast(f.Equals, false)
+	r.atMost(f.Equals, false)
+	r.atLeast(f.GreaterThan, true)
+	r.atLeast(f.GreaterThanOrEqualTo, false)
+	r.atMost(f.LessThan, true)
+	r.atMost(f.LessThanOrEqualTo, false)
 
-		return "(" + strings.Join(individualSQLStrings, " OR ") + ")", individualParameters
+	if f.Between != nil {
+		r.atLeast(&f.Between[0], false)
+		r.atMost(&f.Between[1], false)
 	}
 
-	return "", []any{}
+	return r
 }
 
 func IntEquals(value int) *IntFilter {
@@ -104,6 +143,30 @@ func IntIsNot(value int) *IntFilter {
 	}
 }
 
+func IntIn(values []int) *IntFilter {
+	return &IntFilter{
+		In: &values,
+	}
+}
+
+func IntNotIn(values []int) *IntFilter {
+	return &IntFilter{
+		NotIn: &values,
+	}
+}
+
+func IntBetween(min int, max int) *IntFilter {
+	return &IntFilter{
+		Between: &[2]int{min, max},
+	}
+}
+
+func IntNotBetween(min int, max int) *IntFilter {
+	return &IntFilter{
+		NotBetween: &[2]int{min, max},
+	}
+}
+
 func IntIsNull(value bool) *IntFilter {
 	return &IntFilter{
 		IsNull: &value,
@@ -121,3 +184,74 @@ func IntOr(values []*IntFilter) *IntFilter {
 		Or: &values,
 	}
 }
+
+func intParams(values []int) []any {
+	params := []any{}
+	for _, value := range values {
+		params = append(params, strconv.Itoa(value))
+	}
+	return params
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *IntFilter) Match(value *int) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to an int or *int model field using SQL three valued logic
+func (f *IntFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[int](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *IntFilter) evaluate(value *int) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v == *f.Equals }))
+	}
+	if f.LessThan != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v < *f.LessThan }))
+	}
+	if f.LessThanOrEqualTo != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v <= *f.LessThanOrEqualTo }))
+	}
+	if f.GreaterThan != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v > *f.GreaterThan }))
+	}
+	if f.GreaterThanOrEqualTo != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v >= *f.GreaterThanOrEqualTo }))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v != *f.IsNot }))
+	}
+	if f.In != nil {
+		truth = truth.And(inTruth(value, *f.In))
+	}
+	if f.NotIn != nil {
+		truth = truth.And(notInTruth(value, *f.NotIn))
+	}
+	if f.Between != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v >= f.Between[0] && v <= f.Between[1] }))
+	}
+	if f.NotBetween != nil {
+		truth = truth.And(compareTruth(value, func(v int) bool { return v < f.NotBetween[0] || v > f.NotBetween[1] }))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *IntFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
@@ -0,0 +1,450 @@
+package filters
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+// JSONFilter compares the value found at Path inside a JSON column, such as $.theme or
+// $.tags[0]. Values are compared as JSON so numbers, strings and booleans keep their types.
+type JSONFilter struct {
+	// defaults to the whole document
+	Path string
+
+	Equals               any
+	IsNot                any
+	LessThan             any
+	GreaterThan          any
+	LessThanOrEqualTo    any
+	GreaterThanOrEqualTo any
+	// the value at Path contains the given JSON value, see JSON_CONTAINS
+	Contains any
+	// the path is missing or holds a JSON null
+	IsNull *bool
+	Or     *[]*JSONFilter
+	And    *[]*JSONFilter
+}
+
+func (f *JSONFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	path := f.Path
+	if path == "" {
+		path = "$"
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		return "", nil, errors.New("filter on " + columnKey + " has a JSON path that does not start with $")
+	}
+
+	dialect := util.GetDialect()
+	if dialect != util.DialectMySQL && dialect != util.DialectSQLite {
+		return "", nil, errors.New("filter on " + columnKey + " uses JSON paths which the " + dialect.String() + " dialect does not support")
+	}
+
+	extract := "JSON_EXTRACT(" + columnKey + ", ?)"
+	if dialect == util.DialectSQLite {
+		extract = "json_extract(" + columnKey + ", ?)"
+	}
+
+	b := filterSQL{}
+
+	comparisons := []struct {
+		operator string
+		value    any
+	}{
+		{"=", f.Equals},
+		{"!=", f.IsNot},
+		{"<", f.LessThan},
+		{">", f.GreaterThan},
+		{"<=", f.LessThanOrEqualTo},
+		{">=", f.GreaterThanOrEqualTo},
+	}
+
+	for _, comparison := range comparisons {
+		if comparison.value == nil {
+			continue
+		}
+
+		if dialect == util.DialectSQLite {
+			// sqlite's json_extract returns plain SQL values
+			b.add(extract+" "+comparison.operator+" ?", path, comparison.value)
+			continue
+		}
+
+		value, err := json.Marshal(comparison.value)
+		if err != nil {
+			return "", nil, err
+		}
+		b.add(extract+" "+comparison.operator+" CAST(? AS JSON)", path, string(value))
+	}
+
+	if f.Contains != nil {
+		if dialect != util.DialectMySQL {
+			return "", nil, errors.New("filter on " + columnKey + " uses Contains which the " + dialect.String() + " dialect does not support")
+		}
+
+		value, err := json.Marshal(f.Contains)
+		if err != nil {
+			return "", nil, err
+		}
+		b.add("JSON_CONTAINS("+columnKey+", ?, ?)", string(value), path)
+	}
+
+	if f.IsNull != nil {
+		isNull := extract + " IS NULL"
+		if dialect == util.DialectMySQL {
+			// mysql keeps a JSON null distinct from a missing path
+			isNull = "COALESCE(JSON_TYPE(" + extract + "), 'NULL') = 'NULL'"
+		}
+
+		if *f.IsNull {
+			b.isNull = true
+			b.add(isNull, path)
+		} else {
+			b.add("NOT "+isNull, path)
+		}
+	}
+
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+func JSONPathEquals(path string, value any) *JSONFilter {
+	return &JSONFilter{
+		Path:   path,
+		Equals: value,
+	}
+}
+
+func JSONPathContains(path string, value any) *JSONFilter {
+	return &JSONFilter{
+		Path:     path,
+		Contains: value,
+	}
+}
+
+func JSONPathIsNull(path string, value bool) *JSONFilter {
+	return &JSONFilter{
+		Path:   path,
+		IsNull: &value,
+	}
+}
+
+func JSONAnd(values []*JSONFilter) *JSONFilter {
+	return &JSONFilter{
+		And: &values,
+	}
+}
+
+func JSONOr(values []*JSONFilter) *JSONFilter {
+	return &JSONFilter{
+		Or: &values,
+	}
+}
+
+// Match reports whether value satisfies the filter the same way the generated MySQL would,
+// a nil value is treated as NULL. Paths may use object keys and array indexes but not wildcards.
+func (f *JSONFilter) Match(value any) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to any JSON encodable model field using SQL three valued logic
+func (f *JSONFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	document, isNull, err := jsonDocument(value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(document, isNull)
+}
+
+func (f *JSONFilter) evaluate(document any, isNull bool) (util.Truth, error) {
+	path := f.Path
+	if path == "" {
+		path = "$"
+	}
+
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	extracted, found := any(nil), false
+	if !isNull {
+		extracted, found = extractJSONPath(document, steps)
+	}
+
+	truth := util.TruthTrue
+
+	comparisons := []struct {
+		value   any
+		matches func(order int) bool
+	}{
+		{f.Equals, func(order int) bool { return order == 0 }},
+		{f.IsNot, func(order int) bool { return order != 0 }},
+		{f.LessThan, func(order int) bool { return order < 0 }},
+		{f.GreaterThan, func(order int) bool { return order > 0 }},
+		{f.LessThanOrEqualTo, func(order int) bool { return order <= 0 }},
+		{f.GreaterThanOrEqualTo, func(order int) bool { return order >= 0 }},
+	}
+
+	for _, comparison := range comparisons {
+		if comparison.value == nil {
+			continue
+		}
+		if !found {
+			truth = truth.And(util.TruthUnknown)
+			continue
+		}
+
+		other, _, err := jsonDocument(comparison.value)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		order, err := compareJSON(extracted, other)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		truth = truth.And(util.TruthOf(comparison.matches(order)))
+	}
+
+	if f.Contains != nil {
+		if !found {
+			truth = truth.And(util.TruthUnknown)
+		} else {
+			candidate, _, err := jsonDocument(f.Contains)
+			if err != nil {
+				return util.TruthUnknown, err
+			}
+			truth = truth.And(util.TruthOf(containsJSON(extracted, candidate)))
+		}
+	}
+
+	if f.IsNull != nil {
+		truth = truth.And(util.TruthOf((!found || extracted == nil) == *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *JSONFilter) (util.Truth, error) {
+		return filter.evaluate(document, isNull)
+	})
+}
+
+// jsonDocument normalises value to the types encoding/json decodes into, a nil map, slice or
+// pointer is a NULL column
+func jsonDocument(value any) (any, bool, error) {
+	if value == nil {
+		return nil, true, nil
+	}
+
+	rValue := reflect.ValueOf(value)
+	switch rValue.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Interface:
+		if rValue.IsNil() {
+			return nil, true, nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var document any
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return nil, false, err
+	}
+
+	return document, false, nil
+}
+
+type jsonPathStep struct {
+	key   string
+	index int
+}
+
+// parseJSONPath splits a path made of .key, ."quoted key" and [index] steps, an index step
+// has an empty key
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("JSON path " + path + " does not start with $")
+	}
+
+	unsupported := errors.New("JSON path " + path + " uses a step that cannot be evaluated in memory")
+	steps := []jsonPathStep{}
+	rest := path[1:]
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+
+			var key string
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.Index(rest[1:], `"`)
+				if end < 0 {
+					return nil, errors.New("JSON path " + path + " has an unterminated key")
+				}
+				key, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, ".[")
+				if end < 0 {
+					end = len(rest)
+				}
+				key, rest = rest[:end], rest[end:]
+
+				if key == "" || strings.Contains(key, "*") {
+					return nil, unsupported
+				}
+			}
+
+			steps = append(steps, jsonPathStep{key: key})
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, errors.New("JSON path " + path + " has an unterminated index")
+			}
+
+			index, err := strconv.Atoi(strings.TrimSpace(rest[1:end]))
+			if err != nil || index < 0 {
+				return nil, unsupported
+			}
+			rest = rest[end+1:]
+
+			steps = append(steps, jsonPathStep{index: index})
+		default:
+			return nil, errors.New("JSON path " + path + " is invalid")
+		}
+	}
+
+	return steps, nil
+}
+
+// extractJSONPath returns the value the steps lead to and whether it exists in document
+func extractJSONPath(document any, steps []jsonPathStep) (any, bool) {
+	current := document
+
+	for _, step := range steps {
+		if step.key != "" {
+			object, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[step.key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		array, ok := current.([]any)
+		if !ok || step.index >= len(array) {
+			return nil, false
+		}
+		current = array[step.index]
+	}
+
+	return current, true
+}
+
+// jsonTypeRank follows mysql's precedence for comparing JSON values of different types
+func jsonTypeRank(value any) int {
+	switch value.(type) {
+	case bool:
+		return 5
+	case []any:
+		return 4
+	case map[string]any:
+		return 3
+	case string:
+		return 2
+	case float64:
+		return 1
+	}
+	return 0
+}
+
+func compareJSON(a any, b any) (int, error) {
+	rankA, rankB := jsonTypeRank(a), jsonTypeRank(b)
+	if rankA != rankB {
+		return cmp.Compare(rankA, rankB), nil
+	}
+
+	switch a := a.(type) {
+	case float64:
+		return cmp.Compare(a, b.(float64)), nil
+	case string:
+		return strings.Compare(a, b.(string)), nil
+	case bool:
+		if a == b.(bool) {
+			return 0, nil
+		}
+		if a {
+			return 1, nil
+		}
+		return -1, nil
+	case nil:
+		return 0, nil
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return 0, nil
+	}
+	return 0, errors.New("JSON arrays and objects can only be compared for equality in memory")
+}
+
+// containsJSON mirrors JSON_CONTAINS
+func containsJSON(target any, candidate any) bool {
+	switch target := target.(type) {
+	case []any:
+		if candidates, ok := candidate.([]any); ok {
+			for _, element := range candidates {
+				if !containsJSON(target, element) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, element := range target {
+			if containsJSON(element, candidate) {
+				return true
+			}
+		}
+		return false
+	case map[string]any:
+		candidateObject, ok := candidate.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for key, value := range candidateObject {
+			targetValue, ok := target[key]
+			if !ok || !containsJSON(targetValue, value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	order, err := compareJSON(target, candidate)
+	return err == nil && order == 0
+}
@@ -0,0 +1,101 @@
+package filters
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+// nullableValue reads a model field value, either T or *T, a nil pointer is NULL
+func nullableValue[T any](value any) (*T, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	if typed, ok := value.(T); ok {
+		return &typed, nil
+	}
+	if typed, ok := value.(*T); ok {
+		return typed, nil
+	}
+
+	rValue := reflect.ValueOf(value)
+	if rValue.Kind() == reflect.Pointer {
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		rValue = rValue.Elem()
+	}
+
+	target := reflect.TypeFor[T]()
+	if !sameKindFamily(rValue.Kind(), target.Kind()) || !rValue.Type().ConvertibleTo(target) {
+		return nil, fmt.Errorf("cannot compare a %s value with a %s filter", rValue.Type(), target)
+	}
+
+	converted := rValue.Convert(target).Interface().(T)
+	return &converted, nil
+}
+
+func sameKindFamily(a reflect.Kind, b reflect.Kind) bool {
+	isInt := func(kind reflect.Kind) bool { return kind >= reflect.Int && kind <= reflect.Int64 }
+	isFloat := func(kind reflect.Kind) bool { return kind == reflect.Float32 || kind == reflect.Float64 }
+
+	return a == b || (isInt(a) && isInt(b)) || (isFloat(a) && isFloat(b))
+}
+
+// compareTruth applies predicate to a non NULL value, comparisons with NULL are unknown
+func compareTruth[T any](value *T, predicate func(T) bool) util.Truth {
+	if value == nil {
+		return util.TruthUnknown
+	}
+	return util.TruthOf(predicate(*value))
+}
+
+// inTruth mirrors inSQL, an empty list never matches even for NULL values
+func inTruth[T comparable](value *T, values []T) util.Truth {
+	if len(values) == 0 {
+		return util.TruthFalse
+	}
+	return compareTruth(value, func(v T) bool { return slices.Contains(values, v) })
+}
+
+// notInTruth mirrors inSQL, an empty list always matches even for NULL values
+func notInTruth[T comparable](value *T, values []T) util.Truth {
+	if len(values) == 0 {
+		return util.TruthTrue
+	}
+	return compareTruth(value, func(v T) bool { return !slices.Contains(values, v) })
+}
+
+func isNullTruth[T any](value *T, isNull bool) util.Truth {
+	return util.TruthOf((value == nil) == isNull)
+}
+
+// withGroups combines truth with the nested And and Or filters the same way addGroup does
+func withGroups[filterT any](truth util.Truth, and *[]filterT, or *[]filterT, evaluate func(filterT) (util.Truth, error)) (util.Truth, error) {
+	if and != nil {
+		for _, filter := range *and {
+			memberTruth, err := evaluate(filter)
+			if err != nil {
+				return util.TruthUnknown, err
+			}
+			truth = truth.And(memberTruth)
+		}
+	}
+
+	if or != nil {
+		anyTruth := util.TruthFalse
+		for _, filter := range *or {
+			memberTruth, err := evaluate(filter)
+			if err != nil {
+				return util.TruthUnknown, err
+			}
+			anyTruth = anyTruth.Or(memberTruth)
+		}
+		truth = truth.And(anyTruth)
+	}
+
+	return truth, nil
+}
@@ -0,0 +1,183 @@
+package filters_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestMatch(t *testing.T) {
+	noon := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	settings := map[string]any{"theme": "dark", "fontSize": 14, "tags": []string{"a", "b"}, "beta": nil}
+
+	tests := []struct {
+		name  string
+		match func() (bool, error)
+		want  bool
+	}{
+		{"int equals", func() (bool, error) { return filters.IntEquals(3).Match(util.ToPointer(3)) }, true},
+		{"int comparison with null", func() (bool, error) { return filters.IntLessThan(3).Match(nil) }, false},
+		{"int not in with null", func() (bool, error) { return filters.IntNotIn([]int{1}).Match(nil) }, false},
+		{"int empty not in matches null", func() (bool, error) { return filters.IntNotIn([]int{}).Match(nil) }, true},
+		{"int is null", func() (bool, error) { return filters.IntIsNull(true).Match(nil) }, true},
+		{"int not between", func() (bool, error) { return filters.IntNotBetween(1, 5).Match(util.ToPointer(6)) }, true},
+		{"int or", func() (bool, error) {
+			return filters.IntOr([]*filters.IntFilter{filters.IntEquals(1), filters.IntIsNull(true)}).Match(nil)
+		}, true},
+		{"string contains follows the case insensitive collation", func() (bool, error) { return filters.StrContains("DM").Match(util.ToPointer("admin")) }, true},
+		{"string case insensitive in", func() (bool, error) {
+			return (&filters.StringFilter{In: &[]string{"ADMIN"}, CaseInsensitive: true}).Match(util.ToPointer("Admin"))
+		}, true},
+		{"string wildcards are literal", func() (bool, error) { return filters.StrStartsWith("50%").Match(util.ToPointer("500")) }, false},
+		{"string ends with", func() (bool, error) { return filters.StrEndsWith(".com").Match(util.ToPointer("a@b.com")) }, true},
+		{"string matches", func() (bool, error) { return filters.StrMatches("^a.+m$").Match(util.ToPointer("a@b.com")) }, true},
+		{"string not contains null", func() (bool, error) { return filters.StrNotContains("x").Match(nil) }, false},
+		{"bool is not", func() (bool, error) { return filters.BoolIsNot(true).Match(util.ToPointer(false)) }, true},
+		{"bool is not null", func() (bool, error) { return filters.BoolIsNot(true).Match(nil) }, false},
+		{"time between is inclusive", func() (bool, error) { return filters.TimeBetween(noon, noon.Add(time.Hour)).Match(&noon) }, true},
+		{"time on date", func() (bool, error) { return filters.TimeOnDate(noon.Add(11 * time.Hour)).Match(&noon) }, true},
+		{"time after null", func() (bool, error) { return filters.TimeAfter(noon).Match(nil) }, false},
+		{"float in", func() (bool, error) { return filters.FloatIn([]float64{1.5, 2.5}).Match(util.ToPointer(2.5)) }, true},
+		{"decimal equals across scales", func() (bool, error) {
+			return filters.DecimalEquals(big.NewRat(199, 10)).Match(big.NewRat(1990, 100))
+		}, true},
+		{"json path equals", func() (bool, error) { return filters.JSONPathEquals("$.theme", "dark").Match(settings) }, true},
+		{"json numbers compare numerically", func() (bool, error) {
+			return (&filters.JSONFilter{Path: "$.fontSize", GreaterThan: 9}).Match(settings)
+		}, true},
+		{"json array index", func() (bool, error) { return filters.JSONPathEquals("$.tags[1]", "b").Match(settings) }, true},
+		{"json contains", func() (bool, error) { return filters.JSONPathContains("$.tags", "a").Match(settings) }, true},
+		{"json missing path is null", func() (bool, error) { return filters.JSONPathIsNull("$.missing", true).Match(settings) }, true},
+		{"json null value is null", func() (bool, error) { return filters.JSONPathIsNull("$.beta", true).Match(settings) }, true},
+		{"json comparison on missing path", func() (bool, error) { return filters.JSONPathEquals("$.missing", 1).Match(settings) }, false},
+		{"json null column", func() (bool, error) { return filters.JSONPathIsNull("$.theme", true).Match(map[string]any(nil)) }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.match()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != test.want {
+				t.Errorf("Match = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		match func() (bool, error)
+	}{
+		{"empty filter", func() (bool, error) { return (&filters.IntFilter{}).Match(util.ToPointer(1)) }},
+		{"inverted between", func() (bool, error) { return filters.IntBetween(5, 1).Match(util.ToPointer(1)) }},
+		{"invalid regular expression", func() (bool, error) { return filters.StrMatches("(").Match(util.ToPointer("a")) }},
+		{"json wildcard path", func() (bool, error) { return filters.JSONPathEquals("$.tags[*]", "a").Match(map[string]any{}) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.match(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// TestStringCollation checks the SQL run for a filter next to what Match returns under each
+// collation, so the two cannot disagree on which rows a filter keeps
+func TestStringCollation(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *filters.StringFilter
+		value  string
+		sql    string
+		params []any
+		// expected matches under mysql's default collation, a binary collation and sqlite's
+		// BINARY collation, whose LIKE still ignores ASCII case
+		caseInsensitive bool
+		binary          bool
+		sqlite          bool
+	}{
+		{"equals", filters.StrEquals("ADMIN"), "admin", "email = ?", []any{"ADMIN"}, true, false, false},
+		{"contains", filters.StrContains("DM"), "admin", "email LIKE ? ESCAPE '!'", []any{"%DM%"}, true, false, true},
+		{"starts with", filters.StrStartsWith("Ad"), "admin", "email LIKE ? ESCAPE '!'", []any{"Ad%"}, true, false, true},
+		{"in", filters.StrIn([]string{"ADMIN"}), "admin", "email IN (?)", []any{"ADMIN"}, true, false, false},
+		{"is not", filters.StrIsNot("ADMIN"), "admin", "email != ?", []any{"ADMIN"}, false, true, true},
+		{"between", filters.StrBetween("A", "B"), "apple", "email BETWEEN ? AND ?", []any{"A", "B"}, true, false, false},
+		{"case insensitive filter", &filters.StringFilter{Equals: util.ToPointer("ADMIN"), CaseInsensitive: true}, "admin", "LOWER(email) = ?", []any{"admin"}, true, true, true},
+	}
+
+	collations := []struct {
+		name      string
+		dialect   util.Dialect
+		collation util.Collation
+		want      func(test int) bool
+	}{
+		{"case insensitive", util.DialectMySQL, util.CollationCaseInsensitive, func(test int) bool { return tests[test].caseInsensitive }},
+		{"binary", util.DialectMySQL, util.CollationBinary, func(test int) bool { return tests[test].binary }},
+		{"sqlite", util.DialectSQLite, util.CollationBinary, func(test int) bool { return tests[test].sqlite }},
+	}
+
+	t.Cleanup(func() {
+		util.SetDialect(util.DialectMySQL)
+		util.SetCollation(util.CollationCaseInsensitive)
+	})
+
+	for _, collation := range collations {
+		util.SetDialect(collation.dialect)
+		util.SetCollation(collation.collation)
+
+		for i, test := range tests {
+			t.Run(collation.name+" "+test.name, func(t *testing.T) {
+				sql, params, err := test.filter.SQL("email")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if sql != test.sql || !reflect.DeepEqual(params, test.params) {
+					t.Errorf("SQL = %q %v, want %q %v", sql, params, test.sql, test.params)
+				}
+
+				got, err := test.filter.Match(&test.value)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if want := collation.want(i); got != want {
+					t.Errorf("Match(%q) = %v, want %v", test.value, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestStringCollationRanges(t *testing.T) {
+	t.Cleanup(func() { util.SetCollation(util.CollationCaseInsensitive) })
+
+	// "a" sorts before "B" when case is ignored and after it when bytes are compared
+	filter := filters.StrBetween("a", "B")
+
+	if _, _, err := filter.SQL("email"); err != nil {
+		t.Errorf("unexpected SQL error under a case insensitive collation: %v", err)
+	}
+	if _, err := filter.Match(util.ToPointer("a")); err != nil {
+		t.Errorf("unexpected Match error under a case insensitive collation: %v", err)
+	}
+
+	util.SetCollation(util.CollationBinary)
+
+	if _, _, err := filter.SQL("email"); err == nil {
+		t.Error("expected an SQL error for an inverted range under a binary collation")
+	}
+	if _, err := filter.Match(util.ToPointer("a")); err == nil {
+		t.Error("expected a Match error for an inverted range under a binary collation")
+	}
+}
@@ -0,0 +1,134 @@
+package filters
+
+import (
+	"strings"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type RelationWherer interface {
+	SQL() (string, []any, error)
+}
+
+// RelationFilter matches rows by their related rows. Set fields are combined with AND.
+type RelationFilter[whereT RelationWherer] struct {
+	// at least one related row matches
+	Some *whereT
+	// every related row matches, also true when there are no related rows
+	Every *whereT
+	// no related row matches
+	None *whereT
+}
+
+func (f *RelationFilter[whereT]) RelationSQL(relation util.WhereRelation) (string, []any, error) {
+	individualSQLStrings := []string{}
+	individualParameters := []any{}
+
+	if f.Some != nil {
+		where, params, err := (*f.Some).SQL()
+		if err != nil {
+			return "", nil, err
+		}
+
+		individualSQLStrings = append(individualSQLStrings, relation.ExistsSQL(where))
+		individualParameters = append(individualParameters, params...)
+	}
+
+	if f.Every != nil {
+		where, params, err := (*f.Every).SQL()
+		if err != nil {
+			return "", nil, err
+		}
+
+		// an empty filter matches every related row so there is nothing to check
+		if where != "" {
+			individualSQLStrings = append(individualSQLStrings, "NOT "+relation.ExistsSQL("NOT ("+where+")"))
+			individualParameters = append(individualParameters, params...)
+		}
+	}
+
+	if f.None != nil {
+		where, params, err := (*f.None).SQL()
+		if err != nil {
+			return "", nil, err
+		}
+
+		individualSQLStrings = append(individualSQLStrings, "NOT "+relation.ExistsSQL(where))
+		individualParameters = append(individualParameters, params...)
+	}
+
+	if len(individualSQLStrings) == 0 {
+		return "", []any{}, nil
+	}
+
+	return "(" + strings.Join(individualSQLStrings, " AND ") + ")", individualParameters, nil
+}
+
+// EvaluateRelated applies the filter to the loaded related models the way the EXISTS
+// subqueries would, a related model only counts as matching when its where is true
+func (f *RelationFilter[whereT]) EvaluateRelated(related []any) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	count := func(where whereT, want util.Truth) (int, error) {
+		if _, _, err := where.SQL(); err != nil {
+			return 0, err
+		}
+
+		matches := 0
+		for _, model := range related {
+			modelTruth, err := util.EvaluateWhere(where, model)
+			if err != nil {
+				return 0, err
+			}
+			if modelTruth == want {
+				matches++
+			}
+		}
+		return matches, nil
+	}
+
+	if f.Some != nil {
+		matches, err := count(*f.Some, util.TruthTrue)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		truth = truth.And(util.TruthOf(matches > 0))
+	}
+
+	if f.Every != nil {
+		// NOT EXISTS (... NOT (where)) only rejects related rows where the filter is false
+		failures, err := count(*f.Every, util.TruthFalse)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		truth = truth.And(util.TruthOf(failures == 0))
+	}
+
+	if f.None != nil {
+		matches, err := count(*f.None, util.TruthTrue)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+		truth = truth.And(util.TruthOf(matches == 0))
+	}
+
+	return truth, nil
+}
+
+func RelationSome[whereT RelationWherer](where whereT) *RelationFilter[whereT] {
+	return &RelationFilter[whereT]{
+		Some: &where,
+	}
+}
+
+func RelationEvery[whereT RelationWherer](where whereT) *RelationFilter[whereT] {
+	return &RelationFilter[whereT]{
+		Every: &where,
+	}
+}
+
+func RelationNone[whereT RelationWherer](where whereT) *RelationFilter[whereT] {
+	return &RelationFilter[whereT]{
+		None: &where,
+	}
+}
@@ -1,57 +1,148 @@
 package filters
 
-import "strings"
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"smithsolutions/go-api/internal/util"
+)
 
 type StringFilter struct {
-	Equals   *string
-	Contains *string
-	IsNot    *string
-	IsNull   *bool
-	Or       *[]*StringFilter
-	And      *[]*StringFilter
+	Equals      *string
+	Contains    *string
+	NotContains *string
+	StartsWith  *string
+	EndsWith    *string
+	// regular expression match, only available when the dialect supports it
+	Matches    *string
+	IsNot      *string
+	In         *[]string
+	NotIn      *[]string
+	Between    *[2]string
+	NotBetween *[2]string
+	IsNull     *bool
+	Or         *[]*StringFilter
+	And        *[]*StringFilter
+
+	// compares values regardless of case, nested Or and And filters set their own mode
+	CaseInsensitive bool
 }
 
-func (f *StringFilter) SQL(columnKey string) (string, []any) {
+// LIKE patterns escape wildcards with ! since backslash escaping differs between dialects
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+func (f *StringFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	column := columnKey
+	value := func(value string) string { return value }
+
+	if f.CaseInsensitive {
+		column = "LOWER(" + columnKey + ")"
+		value = strings.ToLower
+	}
+
+	// checks for conflicting values compare them the way the column does
+	collate := f.collate()
+
+	b := filterSQL{}
+
+	like := func(operator string, pattern string) {
+		b.add(column+" "+operator+" ? ESCAPE '"+likeEscape+"'", pattern)
+	}
+
 	if f.Equals != nil {
-		return columnKey + " = ?", []any{*f.Equals}
-	} else if f.Contains != nil {
-		return columnKey + " LIKE ?", []any{"%" + *f.Contains + "%"}
-	} else if f.IsNot != nil {
-		return columnKey + " != ?", []any{*f.IsNot}
-	} else if f.IsNull != nil {
-		if *f.IsNull {
-			return columnKey + " IS NULL", []any{}
-		} else {
-			return columnKey + " IS NOT NULL", []any{}
-		}
-	} else if f.And != nil {
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.And {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
+		b.add(column+" = ?", value(*f.Equals))
+	}
+	if f.Contains != nil {
+		like("LIKE", "%"+likeEscaper.Replace(value(*f.Contains))+"%")
+	}
+	if f.NotContains != nil {
+		like("NOT LIKE", "%"+likeEscaper.Replace(value(*f.NotContains))+"%")
+	}
+	if f.StartsWith != nil {
+		like("LIKE", likeEscaper.Replace(value(*f.StartsWith))+"%")
+	}
+	if f.EndsWith != nil {
+		like("LIKE", "%"+likeEscaper.Replace(value(*f.EndsWith)))
+	}
+	if f.Matches != nil {
+		switch util.GetDialect() {
+		case util.DialectMySQL:
+			matchType := "c"
+			if f.CaseInsensitive {
+				matchType = "i"
 			}
-
-			individualParameters = append(individualParameters, parameters...)
+			b.add("REGEXP_LIKE("+columnKey+", ?, '"+matchType+"')", *f.Matches)
+		default:
+			return "", nil, errors.New("filter on " + columnKey + " uses Matches which the " + util.GetDialect().String() + " dialect does not support")
 		}
+	}
+	if f.IsNot != nil {
+		if f.Equals != nil && collate(*f.Equals) == collate(*f.IsNot) {
+			return "", nil, errors.New("filter on " + columnKey + " cannot both equal and not equal " + *f.IsNot)
+		}
+		b.add(column+" != ?", value(*f.IsNot))
+	}
+	if f.In != nil {
+		sql, params := inSQL(column, "IN", stringParams(mapStrings(*f.In, value)))
+		b.add(sql, params...)
+	}
+	if f.NotIn != nil {
+		sql, params := inSQL(column, "NOT IN", stringParams(mapStrings(*f.NotIn, value)))
+		b.add(sql, params...)
+	}
+	if f.Between != nil {
+		if collate(f.Between[0]) > collate(f.Between[1]) {
+			return "", nil, errors.New("filter on " + columnKey + " has a Between range that starts after it ends")
+		}
+		b.add(column+" BETWEEN ? AND ?", stringParams(mapStrings(f.Between[:], value))...)
+	}
+	if f.NotBetween != nil {
+		if collate(f.NotBetween[0]) > collate(f.NotBetween[1]) {
+			return "", nil, errors.New("filter on " + columnKey + " has a NotBetween range that starts after it ends")
+		}
+		b.add(column+" NOT BETWEEN ? AND ?", stringParams(mapStrings(f.NotBetween[:], value))...)
+	}
 
-		return "(" + strings.Join(individualSQLStrings, " AND ") + ")", individualParameters
-	} else if f.Or != nil {
-		individualSQLStrings := []string{}
-		individualParameters := []any{}
-		for _, filter := range *f.Or {
-			sqlStr, parameters := filter.SQL(columnKey)
-			if sqlStr != "" {
-				individualSQLStrings = append(individualSQLStrings, sqlStr)
-			}
+	if f.comparedRange(collate).empty() {
+		return "", nil, errors.New("filter on " + columnKey + " has comparisons that no value can match")
+	}
 
-			individualParameters = append(individualParameters, parameters...)
-		}
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+// comparedRange is the range of values allowed by Equals and Between, compared after collate
+// maps them the way the column compares them
+func (f *StringFilter) comparedRange(collate func(string) string) *valueRange[string] {
+	r := &valueRange[string]{compare: strings.Compare}
+
+	if f.Equals != nil {
+		equals := collate(*f.Equals)
+		r.atLeast(&equals, false)
+		r.atMost(&equals, false)
+	}
 
-		return "(" + strings.Join(individualSQLStrings, " OR ") + ")", individualParameters
+	if f.Between != nil {
+		lower, upper := collate(f.Between[0]), collate(f.Between[1])
+		r.atLeast(&lower, false)
+		r.atMost(&upper, false)
 	}
-	return "", []any{}
+
+	return r
 }
 
 func StrEquals(value string) *StringFilter {
@@ -66,12 +157,60 @@ func StrContains(value string) *StringFilter {
 	}
 }
 
+func StrNotContains(value string) *StringFilter {
+	return &StringFilter{
+		NotContains: &value,
+	}
+}
+
+func StrStartsWith(value string) *StringFilter {
+	return &StringFilter{
+		StartsWith: &value,
+	}
+}
+
+func StrEndsWith(value string) *StringFilter {
+	return &StringFilter{
+		EndsWith: &value,
+	}
+}
+
+func StrMatches(pattern string) *StringFilter {
+	return &StringFilter{
+		Matches: &pattern,
+	}
+}
+
 func StrIsNot(value string) *StringFilter {
 	return &StringFilter{
 		IsNot: &value,
 	}
 }
 
+func StrIn(values []string) *StringFilter {
+	return &StringFilter{
+		In: &values,
+	}
+}
+
+func StrNotIn(values []string) *StringFilter {
+	return &StringFilter{
+		NotIn: &values,
+	}
+}
+
+func StrBetween(min string, max string) *StringFilter {
+	return &StringFilter{
+		Between: &[2]string{min, max},
+	}
+}
+
+func StrNotBetween(min string, max string) *StringFilter {
+	return &StringFilter{
+		NotBetween: &[2]string{min, max},
+	}
+}
+
 func StrIsNull(value bool) *StringFilter {
 	return &StringFilter{
 		IsNull: &value,
@@ -89,3 +228,142 @@ func StrOr(values []*StringFilter) *StringFilter {
 		Or: &values,
 	}
 }
+
+func stringParams(values []string) []any {
+	params := []any{}
+	for _, value := range values {
+		params = append(params, value)
+	}
+	return params
+}
+
+func mapStrings(values []string, mapper func(string) string) []string {
+	mapped := []string{}
+	for _, value := range values {
+		mapped = append(mapped, mapper(value))
+	}
+	return mapped
+}
+
+// collate maps values the way the column compares them, CaseInsensitive lowers the column in
+// SQL and a case insensitive collation ignores case on its own
+func (f *StringFilter) collate() func(string) string {
+	if f.CaseInsensitive || util.GetCollation() == util.CollationCaseInsensitive {
+		return strings.ToLower
+	}
+	return func(value string) string { return value }
+}
+
+// likeCollate maps values the way LIKE compares them, sqlite's LIKE ignores ASCII case
+// whatever the collation of the column
+func (f *StringFilter) likeCollate() func(string) string {
+	collate := f.collate()
+	if util.GetDialect() != util.DialectSQLite {
+		return collate
+	}
+
+	return func(value string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'A' && r <= 'Z' {
+				return r + 'a' - 'A'
+			}
+			return r
+		}, collate(value))
+	}
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL. Comparisons follow the collation set with util.SetCollation
+// unless CaseInsensitive is set, which always ignores case.
+func (f *StringFilter) Match(value *string) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a string or *string model field using SQL three valued logic
+func (f *StringFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[string](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *StringFilter) evaluate(value *string) (util.Truth, error) {
+	collate := f.collate()
+	likeCollate := f.likeCollate()
+
+	var collated *string
+	if value != nil {
+		collated = util.ToPointer(collate(*value))
+	}
+
+	compare := func(predicate func(v string) bool) util.Truth {
+		return compareTruth(collated, predicate)
+	}
+	like := func(predicate func(v string, pattern string) bool, pattern string) util.Truth {
+		return compareTruth(value, func(v string) bool { return predicate(likeCollate(v), likeCollate(pattern)) })
+	}
+
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compare(func(v string) bool { return v == collate(*f.Equals) }))
+	}
+	if f.Contains != nil {
+		truth = truth.And(like(strings.Contains, *f.Contains))
+	}
+	if f.NotContains != nil {
+		truth = truth.And(like(func(v string, pattern string) bool { return !strings.Contains(v, pattern) }, *f.NotContains))
+	}
+	if f.StartsWith != nil {
+		truth = truth.And(like(strings.HasPrefix, *f.StartsWith))
+	}
+	if f.EndsWith != nil {
+		truth = truth.And(like(strings.HasSuffix, *f.EndsWith))
+	}
+	if f.Matches != nil {
+		pattern := *f.Matches
+		if f.CaseInsensitive {
+			pattern = "(?i)" + pattern
+		}
+
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return util.TruthUnknown, err
+		}
+
+		// REGEXP_LIKE is given the column as stored and its match type overrides the collation
+		truth = truth.And(compareTruth(value, expression.MatchString))
+	}
+	if f.IsNot != nil {
+		truth = truth.And(compare(func(v string) bool { return v != collate(*f.IsNot) }))
+	}
+	if f.In != nil {
+		truth = truth.And(inTruth(collated, mapStrings(*f.In, collate)))
+	}
+	if f.NotIn != nil {
+		truth = truth.And(notInTruth(collated, mapStrings(*f.NotIn, collate)))
+	}
+	if f.Between != nil {
+		start, end := collate(f.Between[0]), collate(f.Between[1])
+		truth = truth.And(compare(func(v string) bool { return v >= start && v <= end }))
+	}
+	if f.NotBetween != nil {
+		start, end := collate(f.NotBetween[0]), collate(f.NotBetween[1])
+		truth = truth.And(compare(func(v string) bool { return v < start || v > end }))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	// nested filters compare the value in their own case mode
+	return withGroups(truth, f.And, f.Or, func(filter *StringFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
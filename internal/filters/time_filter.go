@@ -0,0 +1,184 @@
+package filters
+
+import (
+	"errors"
+	"time"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type TimeFilter struct {
+	Equals  *time.Time
+	Before  *time.Time
+	After   *time.Time
+	Between *[2]time.Time
+	// matches the whole calendar day in the configured time location
+	OnDate *time.Time
+	IsNull *bool
+	Or     *[]*TimeFilter
+	And    *[]*TimeFilter
+}
+
+func (f *TimeFilter) SQL(columnKey string) (string, []any, error) {
+	if f == nil {
+		return "", nil, errors.New("filter on " + columnKey + " is nil")
+	}
+
+	b := filterSQL{}
+
+	if f.Equals != nil {
+		b.add(columnKey+" = ?", util.TimeParam(*f.Equals))
+	}
+	if f.Before != nil {
+		b.add(columnKey+" < ?", util.TimeParam(*f.Before))
+	}
+	if f.After != nil {
+		b.add(columnKey+" > ?", util.TimeParam(*f.After))
+	}
+	if f.Between != nil {
+		if f.Between[0].After(f.Between[1]) {
+			return "", nil, errors.New("filter on " + columnKey + " has a Between range that starts after it ends")
+		}
+		b.add(columnKey+" BETWEEN ? AND ?", util.TimeParam(f.Between[0]), util.TimeParam(f.Between[1]))
+	}
+	if f.OnDate != nil {
+		date := f.OnDate.In(util.GetTimeLocation())
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, util.GetTimeLocation())
+
+		b.add("("+columnKey+" >= ? AND "+columnKey+" < ?)", util.TimeParam(start), util.TimeParam(start.AddDate(0, 0, 1)))
+	}
+
+	if f.comparedRange().empty() {
+		return "", nil, errors.New("filter on " + columnKey + " has comparisons that no time can match")
+	}
+
+	b.addIsNull(columnKey, f.IsNull)
+	if err := addGroup(&b, columnKey, "And", f.And); err != nil {
+		return "", nil, err
+	}
+	if err := addGroup(&b, columnKey, "Or", f.Or); err != nil {
+		return "", nil, err
+	}
+
+	return b.build(columnKey)
+}
+
+// comparedRange is the range of times allowed by Equals, Between, Before and After
+func (f *TimeFilter) comparedRange() *valueRange[time.Time] {
+	r := &valueRange[time.Time]{compare: time.Time.Compare}
+
+	r.atLeast(f.Equals, false)
+	r.atMost(f.Equals, false)
+	r.atLeast(f.After, true)
+	r.atMost(f.Before, true)
+
+	if f.Between != nil {
+		r.atLeast(&f.Between[0], false)
+		r.atMost(&f.Between[1], false)
+	}
+
+	return r
+}
+
+func TimeEquals(value time.Time) *TimeFilter {
+	return &TimeFilter{
+		Equals: &value,
+	}
+}
+
+func TimeBefore(value time.Time) *TimeFilter {
+	return &TimeFilter{
+		Before: &value,
+	}
+}
+
+func TimeAfter(value time.Time) *TimeFilter {
+	return &TimeFilter{
+		After: &value,
+	}
+}
+
+func TimeBetween(start time.Time, end time.Time) *TimeFilter {
+	return &TimeFilter{
+		Between: &[2]time.Time{start, end},
+	}
+}
+
+func TimeOnDate(value time.Time) *TimeFilter {
+	return &TimeFilter{
+		OnDate: &value,
+	}
+}
+
+func TimeIsNull(value bool) *TimeFilter {
+	return &TimeFilter{
+		IsNull: &value,
+	}
+}
+
+func TimeAnd(values []*TimeFilter) *TimeFilter {
+	return &TimeFilter{
+		And: &values,
+	}
+}
+
+func TimeOr(values []*TimeFilter) *TimeFilter {
+	return &TimeFilter{
+		Or: &values,
+	}
+}
+
+// Match reports whether value satisfies the filter the same way the generated SQL would,
+// a nil value is treated as NULL
+func (f *TimeFilter) Match(value *time.Time) (bool, error) {
+	truth, err := f.Evaluate(value)
+	return truth == util.TruthTrue, err
+}
+
+// Evaluate applies the filter to a time.Time or *time.Time model field using SQL three valued logic
+func (f *TimeFilter) Evaluate(value any) (util.Truth, error) {
+	if _, _, err := f.SQL("value"); err != nil {
+		return util.TruthUnknown, err
+	}
+
+	v, err := nullableValue[time.Time](value)
+	if err != nil {
+		return util.TruthUnknown, err
+	}
+
+	return f.evaluate(v)
+}
+
+func (f *TimeFilter) evaluate(value *time.Time) (util.Truth, error) {
+	truth := util.TruthTrue
+
+	if f.Equals != nil {
+		truth = truth.And(compareTruth(value, func(v time.Time) bool { return v.Equal(*f.Equals) }))
+	}
+	if f.Before != nil {
+		truth = truth.And(compareTruth(value, func(v time.Time) bool { return v.Before(*f.Before) }))
+	}
+	if f.After != nil {
+		truth = truth.And(compareTruth(value, func(v time.Time) bool { return v.After(*f.After) }))
+	}
+	if f.Between != nil {
+		truth = truth.And(compareTruth(value, func(v time.Time) bool {
+			return !v.Before(f.Between[0]) && !v.After(f.Between[1])
+		}))
+	}
+	if f.OnDate != nil {
+		date := f.OnDate.In(util.GetTimeLocation())
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, util.GetTimeLocation())
+
+		truth = truth.And(compareTruth(value, func(v time.Time) bool {
+			return !v.Before(start) && v.Before(start.AddDate(0, 0, 1))
+		}))
+	}
+	if f.IsNull != nil {
+		truth = truth.And(isNullTruth(value, *f.IsNull))
+	}
+
+	return withGroups(truth, f.And, f.Or, func(filter *TimeFilter) (util.Truth, error) {
+		return filter.evaluate(value)
+	})
+}
@@ -0,0 +1,44 @@
+package filters
+
+// valueRange narrows down the values allowed by a filter's comparisons, so filters such as
+// GreaterThan 10 with LessThan 5 are rejected rather than sent as a query that never matches
+type valueRange[T any] struct {
+	// orders values the way the database compares them
+	compare     func(a, b T) int
+	lower       *T
+	upper       *T
+	lowerStrict bool
+	upperStrict bool
+}
+
+// atLeast adds a lower bound, strict bounds exclude the value itself
+func (r *valueRange[T]) atLeast(value *T, strict bool) {
+	if value == nil {
+		return
+	}
+	if r.lower == nil || r.compare(*value, *r.lower) > 0 || (r.compare(*value, *r.lower) == 0 && strict) {
+		r.lower = value
+		r.lowerStrict = strict
+	}
+}
+
+// atMost adds an upper bound, strict bounds exclude the value itself
+func (r *valueRange[T]) atMost(value *T, strict bool) {
+	if value == nil {
+		return
+	}
+	if r.upper == nil || r.compare(*value, *r.upper) < 0 || (r.compare(*value, *r.upper) == 0 && strict) {
+		r.upper = value
+		r.upperStrict = strict
+	}
+}
+
+func (r *valueRange[T]) empty() bool {
+	if r.lower == nil || r.upper == nil {
+		return false
+	}
+	if r.compare(*r.lower, *r.upper) == 0 {
+		return r.lowerStrict || r.upperStrict
+	}
+	return r.compare(*r.lower, *r.upper) > 0
+}
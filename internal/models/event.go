@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Event struct {
 	Id int
 
@@ -7,12 +9,14 @@ type Event struct {
 
 	Label          string
 	CoverPhotoPath *string
+	Metadata       map[string]any `orm:"json"`
 
 	// manually added fields
 	CoverPhotoURL *string `orm:"ignore"`
 
-	CreatedAt string
-	UpdatedAt string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 
-	Owner *User
+	Owner     *User
+	Attendees *[]User
 }
@@ -1,13 +1,17 @@
 package models
 
+import "time"
+
 type User struct {
 	Id int
 
 	Email        string
 	PasswordHash string
+	Settings     map[string]any `orm:"json"`
 
-	CreatedAt string
-	UpdatedAt string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 
-	Events *[]Event
+	Events          *[]Event
+	AttendingEvents *[]Event
 }
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+var cursorSecret = func() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}()
+
+// SetCursorSecret sets the key used to sign pagination cursors. Without it a random key is
+// generated on startup, so cursors do not survive restarts or work across instances.
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+type cursorPayload struct {
+	OrderBy []string
+	Values  []any
+	// marks values that have to be decoded back into a time.Time
+	Times []bool
+}
+
+// cursorOrderBy returns the requested ordering with id appended as a tie breaker so every
+// row has a unique position
+func (o QueryOptions) cursorOrderBy() []OrderBy {
+	orderBy := slices.Clone(o.OrderBy)
+	hasId := false
+
+	for i, ordering := range orderBy {
+		if ordering.Direction == "" {
+			orderBy[i].Direction = SortAscending
+		}
+		if ordering.Column == "id" {
+			hasId = true
+		}
+	}
+
+	if hasId {
+		return orderBy
+	}
+
+	idDirection := SortAscending
+	if len(orderBy) > 0 {
+		idDirection = orderBy[len(orderBy)-1].Direction
+	}
+
+	return append(orderBy, OrderBy{Column: "id", Direction: idDirection})
+}
+
+func orderBySignature(orderBy []OrderBy) []string {
+	signature := []string{}
+	for _, ordering := range orderBy {
+		signature = append(signature, ordering.Column+":"+string(ordering.Direction))
+	}
+	return signature
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func encodeCursor(orderBy []OrderBy, values []any) (string, error) {
+	times := make([]bool, len(values))
+	for i, value := range values {
+		// nullable columns are read from pointer fields
+		if rValue := reflect.ValueOf(value); rValue.Kind() == reflect.Pointer {
+			value = nil
+			if !rValue.IsNil() {
+				value = rValue.Elem().Interface()
+			}
+			values[i] = value
+		}
+
+		if timeValue, ok := value.(time.Time); ok {
+			values[i] = timeValue.Format(time.RFC3339Nano)
+			times[i] = true
+		}
+	}
+
+	payload, err := json.Marshal(cursorPayload{
+		OrderBy: orderBySignature(orderBy),
+		Values:  values,
+		Times:   times,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signCursor(payload)), nil
+}
+
+func decodeCursor(cursor string, orderBy []OrderBy) ([]any, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return nil, validationError("malformed cursor")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, validationError("malformed cursor")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, validationError("malformed cursor")
+	}
+
+	if !hmac.Equal(signature, signCursor(payload)) {
+		return nil, validationError("invalid cursor signature")
+	}
+
+	var decoded cursorPayload
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, validationError("malformed cursor")
+	}
+
+	if !slices.Equal(decoded.OrderBy, orderBySignature(orderBy)) || len(decoded.Values) != len(orderBy) {
+		return nil, validationError("cursor does not match the requested ordering")
+	}
+
+	values := []any{}
+	for i, value := range decoded.Values {
+		if number, ok := value.(json.Number); ok {
+			value = number.String()
+		}
+
+		if i < len(decoded.Times) && decoded.Times[i] {
+			timeString, ok := value.(string)
+			if !ok {
+				return nil, validationError("malformed cursor")
+			}
+
+			timeValue, err := time.Parse(time.RFC3339Nano, timeString)
+			if err != nil {
+				return nil, validationError("malformed cursor")
+			}
+			value = util.TimeParam(timeValue)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// seekSQL builds a predicate that matches rows positioned after values in the given ordering:
+// (a > ?) OR (a = ? AND b > ?) OR ...
+// mysql and sqlite sort NULL first in ascending and last in descending order, NULL values are
+// matched with IS NULL so paging continues past them.
+func seekSQL(orderBy []OrderBy, values []any) (string, []any) {
+	branches := []string{}
+	params := []any{}
+
+	for i, ordering := range orderBy {
+		after, afterParams, ok := seekAfter(ordering, values[i])
+		if !ok {
+			continue
+		}
+
+		conditions := []string{}
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				conditions = append(conditions, orderBy[j].Column+" IS NULL")
+				continue
+			}
+			conditions = append(conditions, orderBy[j].Column+" = ?")
+			params = append(params, values[j])
+		}
+
+		conditions = append(conditions, after)
+		params = append(params, afterParams...)
+
+		branches = append(branches, "("+strings.Join(conditions, " AND ")+")")
+	}
+
+	if len(branches) == 0 {
+		return "1 = 0", params
+	}
+
+	return "(" + strings.Join(branches, " OR ") + ")", params
+}
+
+// seekAfter matches values of one column that sort after value, ok is false when nothing does
+func seekAfter(ordering OrderBy, value any) (string, []any, bool) {
+	switch {
+	case value == nil && ordering.Direction == SortDescending:
+		return "", nil, false
+	case value == nil:
+		return ordering.Column + " IS NOT NULL", nil, true
+	case ordering.Direction == SortDescending:
+		return "(" + ordering.Column + " < ? OR " + ordering.Column + " IS NULL)", []any{value}, true
+	}
+	return ordering.Column + " > ?", []any{value}, true
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestSeekSQL(t *testing.T) {
+	ascending := []OrderBy{{Column: "coverPhotoPath", Direction: SortAscending}, {Column: "id", Direction: SortAscending}}
+	descending := []OrderBy{{Column: "coverPhotoPath", Direction: SortDescending}, {Column: "id", Direction: SortDescending}}
+
+	cases := []struct {
+		name    string
+		orderBy []OrderBy
+		values  []any
+		sql     string
+		params  []any
+	}{
+		{
+			name:    "ascending value",
+			orderBy: ascending,
+			values:  []any{"a.png", 4},
+			sql:     "((coverPhotoPath > ?) OR (coverPhotoPath = ? AND id > ?))",
+			params:  []any{"a.png", "a.png", 4},
+		},
+		{
+			name:    "ascending NULL continues into the values after it",
+			orderBy: ascending,
+			values:  []any{nil, 4},
+			sql:     "((coverPhotoPath IS NOT NULL) OR (coverPhotoPath IS NULL AND id > ?))",
+			params:  []any{4},
+		},
+		{
+			name:    "descending value continues into the NULLs after it",
+			orderBy: descending,
+			values:  []any{"a.png", 4},
+			sql:     "(((coverPhotoPath < ? OR coverPhotoPath IS NULL)) OR (coverPhotoPath = ? AND (id < ? OR id IS NULL)))",
+			params:  []any{"a.png", "a.png", 4},
+		},
+		{
+			name:    "descending NULL only continues within the NULLs",
+			orderBy: descending,
+			values:  []any{nil, 4},
+			sql:     "((coverPhotoPath IS NULL AND (id < ? OR id IS NULL)))",
+			params:  []any{4},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, params := seekSQL(c.orderBy, c.values)
+
+			if sql != c.sql {
+				t.Errorf("sql = %q, want %q", sql, c.sql)
+			}
+
+			if !reflect.DeepEqual(params, c.params) {
+				t.Errorf("params = %#v, want %#v", params, c.params)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	orderBy := []OrderBy{
+		{Column: "coverPhotoPath", Direction: SortAscending},
+		{Column: "id", Direction: SortAscending},
+	}
+
+	cursor, err := encodeCursor(orderBy, []any{(*string)(nil), 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values, err := decodeCursor(cursor, orderBy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []any{nil, "7"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %#v, want %#v", values, want)
+	}
+
+	cursor, err = encodeCursor(orderBy, []any{util.ToPointer("a.png"), 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values, err = decodeCursor(cursor, orderBy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if values[0] != "a.png" {
+		t.Errorf("pointer value decoded as %#v", values[0])
+	}
+
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 250000000, time.UTC)
+	cursor, err = encodeCursor([]OrderBy{{Column: "createdAt"}, {Column: "id"}}, []any{createdAt, 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values, err = decodeCursor(cursor, []OrderBy{{Column: "createdAt"}, {Column: "id"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// times are seeked from as parameters in the configured location
+	if values[0] != util.TimeParam(createdAt) {
+		t.Errorf("time value decoded as %#v", values[0])
+	}
+
+	if _, err := decodeCursor(cursor, orderBy[1:]); err == nil {
+		t.Error("expected a cursor for another ordering to be rejected")
+	}
+
+	secret := cursorSecret
+	SetCursorSecret([]byte("another secret"))
+	t.Cleanup(func() { SetCursorSecret(secret) })
+
+	if _, err := decodeCursor(cursor, orderBy); err == nil || err.Error() != "invalid cursor signature" {
+		t.Errorf("expected a cursor signed with another secret to be rejected, got %v", err)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	service, stub := newTestService(t)
+	stub.Query = answerRows(
+		[]driver.Value{int64(1), "a"},
+		[]driver.Value{int64(2), "b"},
+		[]driver.Value{int64(3), "b"},
+	)
+
+	options := QueryOptions{
+		OrderBy:   []OrderBy{{Column: "name"}},
+		Limit:     util.ToPointer(2),
+		UseCursor: true,
+	}
+
+	rows, pageInfo, err := service.GetManyWithOptions(testWhere{}, nil, options)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(*rows) != 2 || pageInfo.NextCursor == nil || pageInfo.Total != nil {
+		t.Fatalf("rows = %+v, page info = %+v", *rows, pageInfo)
+	}
+
+	stub.Query = answerRows([]driver.Value{int64(3), "b"})
+	options.After = pageInfo.NextCursor
+
+	rows, pageInfo, err = service.GetManyWithOptions(testWhere{}, nil, options)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(*rows) != 1 || pageInfo.NextCursor != nil {
+		t.Errorf("rows = %+v, page info = %+v", *rows, pageInfo)
+	}
+
+	want := []string{
+		"SELECT id, name FROM things ORDER BY name ASC, id ASC LIMIT 3",
+		"SELECT id, name FROM things WHERE ((name > ?) OR (name = ? AND id > ?)) ORDER BY name ASC, id ASC LIMIT 3",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+
+	if args := stub.Statements()[1].Args; !reflect.DeepEqual(args, []driver.Value{"b", "b", "2"}) {
+		t.Errorf("seek args = %#v", args)
+	}
+}
+
+func TestCursorPaginationErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		options QueryOptions
+		err     string
+	}{
+		{"no limit", QueryOptions{UseCursor: true}, "cursor pagination requires a limit"},
+		{"offset", QueryOptions{UseCursor: true, Limit: util.ToPointer(1), Offset: util.ToPointer(1)}, "cursor pagination cannot be combined with an offset"},
+		{"malformed cursor", QueryOptions{UseCursor: true, Limit: util.ToPointer(1), After: util.ToPointer("abc")}, "malformed cursor"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, stub := newTestService(t)
+
+			_, _, err := service.GetManyWithOptions(testWhere{}, nil, c.options)
+
+			if err == nil || err.Error() != c.err {
+				t.Errorf("got error %v, want %q", err, c.err)
+			}
+
+			if log := stub.Log(); len(log) != 0 {
+				t.Errorf("expected no statements, got %q", log)
+			}
+		})
+	}
+}
+
+func TestCursorRejectsJSONColumns(t *testing.T) {
+	db, stub := stubdb.Open(t)
+	eventService := NewEventService(db, nil)
+
+	_, _, err := eventService.GetManyWithOptions(WhereEvent{}, nil, QueryOptions{
+		UseCursor: true,
+		Limit:     util.ToPointer(10),
+		OrderBy:   []OrderBy{{Column: "metadata"}},
+	})
+
+	if err == nil || err.Error() != "cannot use cursor pagination ordered by JSON column metadata" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if log := stub.Log(); len(log) != 0 {
+		t.Errorf("expected no statements, got %q", log)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/go-sql-driver/mysql"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+// Kinds of service errors, match them with errors.Is
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrValidation    = errors.New("validation failed")
+	ErrForeignKey    = errors.New("foreign key violation")
+	ErrServiceFailed = errors.New("service failed to setup or is currently in failed state")
+)
+
+// Error is returned by services for failures caused by the caller, such as a duplicate value
+// or a bad filter. Its message never contains SQL.
+type Error struct {
+	// one of ErrNotFound, ErrConflict, ErrValidation or ErrForeignKey
+	Kind error
+	// column the error applies to, when known
+	Field string
+	// unique key a conflict was reported on, when known
+	Key     string
+	Message string
+	// underlying driver error, if any
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func validationError(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+// invalidInput marks an error building SQL from caller input, such as conflicting filters,
+// as a validation error
+func invalidInput(err error) error {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+
+	return &Error{Kind: ErrValidation, Message: err.Error(), Err: err}
+}
+
+// validateInput checks the validate tags of create or update data, failed rules are returned
+// as a validation error wrapping util.ValidationErrors
+func validateInput(data any) error {
+	return validationResult(util.Validate(data))
+}
+
+// validateInputFields is validateInput for the named fields of data only
+func validateInputFields(data any, fields []string) error {
+	return validationResult(util.ValidateFields(data, fields))
+}
+
+func validationResult(err error) error {
+	var validationErrs util.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return &Error{Kind: ErrValidation, Message: err.Error(), Err: err}
+	}
+
+	return err
+}
+
+// mysql server error numbers, see https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlDuplicateEntry       = 1062
+	mysqlBadNull              = 1048
+	mysqlDataTooLong          = 1406
+	mysqlTruncatedWrongValue  = 1366
+	mysqlInvalidJSON          = 3140
+	mysqlRowIsReferenced      = 1451
+	mysqlNoReferencedRow      = 1452
+	mysqlRowIsReferencedOld   = 1217
+	mysqlNoReferencedRowOld   = 1216
+	mysqlCheckConstraintFails = 3819
+)
+
+var (
+	mysqlDuplicateKey = regexp.MustCompile(`for key '(?:[^'.]*\.)?([^']*)'`)
+	mysqlColumn       = regexp.MustCompile(`(?i)column '([^']*)'`)
+	mysqlForeignKey   = regexp.MustCompile("FOREIGN KEY \\(`([^`]*)`\\)")
+)
+
+// translateError turns database errors into service errors, errors it does not recognise are
+// returned unchanged and should be treated as internal
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Message: "not found", Err: err}
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+
+	submatch := func(expression *regexp.Regexp) string {
+		if match := expression.FindStringSubmatch(mysqlErr.Message); match != nil {
+			return match[1]
+		}
+		return ""
+	}
+
+	switch mysqlErr.Number {
+	case mysqlDuplicateEntry:
+		// mysql names the key rather than its columns, see translateWriteError
+		return &Error{Kind: ErrConflict, Key: submatch(mysqlDuplicateKey), Message: "a row with the same value already exists", Err: err}
+	case mysqlBadNull:
+		field := submatch(mysqlColumn)
+		return &Error{Kind: ErrValidation, Field: field, Message: fieldOr(field, "a value") + " is required", Err: err}
+	case mysqlDataTooLong:
+		field := submatch(mysqlColumn)
+		return &Error{Kind: ErrValidation, Field: field, Message: fieldOr(field, "a value") + " is too long", Err: err}
+	case mysqlTruncatedWrongValue, mysqlInvalidJSON, mysqlCheckConstraintFails:
+		field := submatch(mysqlColumn)
+		return &Error{Kind: ErrValidation, Field: field, Message: fieldOr(field, "a value") + " is invalid", Err: err}
+	case mysqlNoReferencedRow, mysqlNoReferencedRowOld:
+		field := submatch(mysqlForeignKey)
+		return &Error{Kind: ErrForeignKey, Field: field, Message: fieldOr(field, "a reference") + " points to a row that does not exist", Err: err}
+	case mysqlRowIsReferenced, mysqlRowIsReferencedOld:
+		return &Error{Kind: ErrForeignKey, Message: "the row is still referenced by other rows", Err: err}
+	}
+
+	return err
+}
+
+// translateWriteError is translateError for statements that can conflict on a unique key. Single
+// column keys are named after their column by default, so a key that matches a column of the
+// service's table is reported as that Field.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) translateWriteError(err error) error {
+	err = translateError(err)
+
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) && serviceErr.Kind == ErrConflict && slices.Contains(s.columns, serviceErr.Key) {
+		serviceErr.Field = serviceErr.Key
+		serviceErr.Message = "a row with the same " + serviceErr.Field + " already exists"
+	}
+
+	return err
+}
+
+func fieldOr(field string, fallback string) string {
+	if field == "" {
+		return fallback
+	}
+	return field
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestTranslateError(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.email'"}
+	noReferencedRow := &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`app`.`events`, CONSTRAINT `events_ibfk_1` FOREIGN KEY (`ownerUserId`) REFERENCES `users` (`id`))"}
+	rowIsReferenced := &mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row: a foreign key constraint fails (`app`.`events`, CONSTRAINT `events_ibfk_1` FOREIGN KEY (`ownerUserId`) REFERENCES `users` (`id`))"}
+	badNull := &mysql.MySQLError{Number: 1048, Message: "Column 'label' cannot be null"}
+	unknown := &mysql.MySQLError{Number: 1064, Message: "You have an error in your SQL syntax"}
+
+	cases := []struct {
+		name    string
+		err     error
+		kind    error
+		field   string
+		key     string
+		message string
+	}{
+		{"no rows", sql.ErrNoRows, ErrNotFound, "", "", "not found"},
+		{"wrapped no rows", fmt.Errorf("scan: %w", sql.ErrNoRows), ErrNotFound, "", "", "not found"},
+		{"duplicate entry", duplicate, ErrConflict, "", "email", "a row with the same value already exists"},
+		{"missing referenced row", noReferencedRow, ErrForeignKey, "ownerUserId", "", "ownerUserId points to a row that does not exist"},
+		{"row is referenced", rowIsReferenced, ErrForeignKey, "", "", "the row is still referenced by other rows"},
+		{"bad null", badNull, ErrValidation, "label", "", "label is required"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := translateError(c.err)
+
+			var serviceErr *Error
+			if !errors.As(err, &serviceErr) {
+				t.Fatalf("expected a service error, got %v", err)
+			}
+
+			if !errors.Is(err, c.kind) {
+				t.Errorf("kind = %v, want %v", serviceErr.Kind, c.kind)
+			}
+
+			if serviceErr.Field != c.field || serviceErr.Key != c.key || serviceErr.Message != c.message {
+				t.Errorf("got field %q key %q message %q, want %q %q %q", serviceErr.Field, serviceErr.Key, serviceErr.Message, c.field, c.key, c.message)
+			}
+
+			if !errors.Is(err, c.err) {
+				t.Error("expected the driver error to stay wrapped")
+			}
+		})
+	}
+
+	if err := translateError(unknown); err != unknown {
+		t.Errorf("expected unknown errors to be returned unchanged, got %v", err)
+	}
+
+	if err := translateError(nil); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+}
+
+func TestTranslateWriteError(t *testing.T) {
+	userService := NewUserService(nil, nil)
+
+	err := userService.translateWriteError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.email'"})
+
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || serviceErr.Field != "email" || serviceErr.Message != "a row with the same email already exists" {
+		t.Errorf("expected a conflict on email, got %#v", err)
+	}
+
+	// composite or custom named keys do not name a column
+	err = userService.translateWriteError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'event_attendees.PRIMARY'"})
+
+	if !errors.As(err, &serviceErr) || serviceErr.Field != "" || serviceErr.Key != "PRIMARY" {
+		t.Errorf("expected a conflict without a field, got %#v", err)
+	}
+}
@@ -1,18 +1,18 @@
 package services
 
 import (
-	"database/sql"
-
 	"smithsolutions/go-api/internal/filters"
 	"smithsolutions/go-api/internal/models"
 	"smithsolutions/go-api/internal/util"
 )
 
+//go:generate gen CreateEvent CreateSQL
 type CreateEvent struct {
-	OwnerUserId int
+	OwnerUserId int `validate:"required"`
 
-	Label          string
-	CoverPhotoPath *string
+	Label          string         `validate:"required,max=800"`
+	CoverPhotoPath *string        `validate:"max=400"`
+	Metadata       map[string]any `orm:"json"`
 }
 
 // func (c CreateEvent) SQL() ([]string, []any, error) {
@@ -20,8 +20,10 @@ type CreateEvent struct {
 // }
 
 type UpdateEvent struct {
-	Label          *string
-	CoverPhotoPath *string
+	// every update writes every field, so a missing label would clear it
+	Label          *string         `validate:"required,max=800"`
+	CoverPhotoPath *string         `validate:"max=400"`
+	Metadata       *map[string]any `orm:"json"`
 }
 
 func (u UpdateEvent) SQL() (string, []any, error) {
@@ -29,15 +31,33 @@ func (u UpdateEvent) SQL() (string, []any, error) {
 }
 
 type WhereEvent struct {
+	Id          *filters.IntFilter
 	OwnerUserId *filters.IntFilter
+	Label       *filters.StringFilter
+	Metadata    *filters.JSONFilter
+	CreatedAt   *filters.TimeFilter
+	UpdatedAt   *filters.TimeFilter
+
+	User      *filters.RelationFilter[WhereUser]
+	Attendees *filters.RelationFilter[WhereUser]
+
+	And *[]WhereEvent
+	Or  *[]WhereEvent
+	Not *WhereEvent
 }
 
 func (w WhereEvent) SQL() (string, []any, error) {
 	return util.GetWhereSQL(w)
 }
 
+// Match applies the filter to a event in memory, relation filters need the relation loaded
+func (w WhereEvent) Match(event *models.Event) (bool, error) {
+	return util.MatchWhere(w, event)
+}
+
 type IncludeWithEvent struct {
-	User bool
+	User      *Include[WhereUser, IncludeWithUser]
+	Attendees *Include[WhereUser, IncludeWithUser]
 }
 
 type EventService struct {
@@ -46,7 +66,7 @@ type EventService struct {
 	userService *UserService
 }
 
-func NewEventService(db *sql.DB, userService *UserService) *EventService {
+func NewEventService(db util.Querier, userService *UserService) *EventService {
 	serviceBase := SetupResourceService[models.Event, CreateEvent, UpdateEvent, WhereEvent, IncludeWithEvent](db, "events", &models.Event{})
 
 	eventService := &EventService{
@@ -54,20 +74,30 @@ func NewEventService(db *sql.DB, userService *UserService) *EventService {
 		userService:     userService,
 	}
 
-	eventService.ResourceService.attachRelationsOverrider = eventService
+	if userService != nil {
+		eventService.SetUserService(userService)
+	}
 
 	return eventService
 }
 
-func (s *EventService) AttachRelations(model *models.Event, include IncludeWithEvent) error {
-	if include.User {
-		user, err := s.userService.GetOneById(model.OwnerUserId, nil)
-		if err != nil {
-			return err
-		}
-
-		model.Owner = user
-	}
-
-	return nil
+// post initialization dependency injection
+func (s *EventService) SetUserService(userService *UserService) {
+	s.userService = userService
+
+	s.DefineRelation(Relation{
+		Name:       "User",
+		Field:      "Owner",
+		Kind:       RelationBelongsTo,
+		Target:     &userService.ResourceService,
+		ForeignKey: "ownerUserId",
+	})
+	s.DefineRelation(Relation{
+		Name:           "Attendees",
+		Kind:           RelationManyToMany,
+		Target:         &userService.ResourceService,
+		JoinTable:      "event_attendees",
+		JoinForeignKey: "eventId",
+		JoinTargetKey:  "userId",
+	})
 }
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type SortDirection string
+
+const (
+	SortAscending  SortDirection = "ASC"
+	SortDescending SortDirection = "DESC"
+)
+
+type OrderBy struct {
+	Column    string
+	Direction SortDirection
+}
+
+type QueryOptions struct {
+	OrderBy []OrderBy
+	Limit   *int
+	Offset  *int
+
+	// when set a second query is run to count every row matching the where clause
+	WithTotal bool
+
+	// keyset pagination, seeks past the After cursor instead of using Offset
+	UseCursor bool
+	After     *string
+}
+
+type PageInfo struct {
+	Total      *int
+	Limit      *int
+	Offset     *int
+	NextCursor *string
+}
+
+func (o QueryOptions) orderBySQL(columns []string) (string, error) {
+	if len(o.OrderBy) == 0 {
+		return "", nil
+	}
+
+	orderings := []string{}
+	for _, orderBy := range o.OrderBy {
+		if !slices.Contains(columns, orderBy.Column) {
+			return "", validationError("cannot order by unknown column " + orderBy.Column)
+		}
+
+		direction := orderBy.Direction
+		if direction == "" {
+			direction = SortAscending
+		}
+
+		if direction != SortAscending && direction != SortDescending {
+			return "", validationError("invalid sort direction " + string(direction))
+		}
+
+		orderings = append(orderings, orderBy.Column+" "+string(direction))
+	}
+
+	return " ORDER BY " + strings.Join(orderings, ", "), nil
+}
+
+func (o QueryOptions) limitSQL() (string, error) {
+	sql := ""
+
+	if o.Limit != nil {
+		if *o.Limit < 0 {
+			return "", validationError("limit cannot be negative")
+		}
+		sql += " LIMIT " + strconv.Itoa(*o.Limit)
+	}
+
+	if o.Offset != nil {
+		if *o.Offset < 0 {
+			return "", validationError("offset cannot be negative")
+		}
+		if o.Limit == nil {
+			return "", validationError("offset requires a limit")
+		}
+		sql += " OFFSET " + strconv.Itoa(*o.Offset)
+	}
+
+	return sql, nil
+}
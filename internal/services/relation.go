@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type RelationKind int
+
+const (
+	// the foreign key lives on this table and points at the target's id
+	RelationBelongsTo RelationKind = iota
+	// the foreign key lives on the target table and points at this row's id
+	RelationHasMany
+	// rows are linked through a join table holding both ids
+	RelationManyToMany
+)
+
+// relations nested deeper than this are rejected to guard against include cycles such as
+// User -> Events -> Owner -> Events -> ...
+const maxIncludeDepth = 5
+
+type Relation struct {
+	// name of the field on includeT that loads this relation, either a bool or an *Include
+	Name string
+	// model field the related rows are attached to, defaults to Name
+	Field string
+
+	Kind       RelationKind
+	Target     RelationTarget
+	ForeignKey string
+
+	// many to many only
+	JoinTable      string
+	JoinForeignKey string
+	JoinTargetKey  string
+}
+
+// Include loads a relation with its own filter, ordering, limit and nested includes
+type Include[whereT Wherer, includeT any] struct {
+	Where   *whereT
+	OrderBy []OrderBy
+	// maximum number of related rows attached to each parent row
+	Limit   *int
+	Include *includeT
+}
+
+type relationQuery struct {
+	where   string
+	params  []any
+	orderBy []OrderBy
+	limit   *int
+	include any
+}
+
+type relationIncluder interface {
+	relationQuery() (relationQuery, error)
+}
+
+func (i Include[whereT, includeT]) relationQuery() (relationQuery, error) {
+	query := relationQuery{
+		orderBy: i.OrderBy,
+		limit:   i.Limit,
+	}
+
+	if i.Where != nil {
+		where, params, err := (*i.Where).SQL()
+		if err != nil {
+			return query, invalidInput(err)
+		}
+		query.where = where
+		query.params = params
+	}
+
+	if i.Include != nil {
+		query.include = i.Include
+	}
+
+	return query, nil
+}
+
+// RelationTarget is implemented by every ResourceService so relations can load rows from
+// services with different type parameters
+type RelationTarget interface {
+	relationTableName() string
+	loadRelated(ctx context.Context, column string, values []any, query relationQuery, depth int) (reflect.Value, error)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) relationTableName() string {
+	return s.tableName
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) loadRelated(ctx context.Context, column string, values []any, query relationQuery, depth int) (reflect.Value, error) {
+	rows, err := s.getManyWhereIn(ctx, column, values, query)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if include, ok := query.include.(*includeT); ok && include != nil && len(rows) > 0 {
+		models := make([]*modelT, len(rows))
+		for i := range rows {
+			models[i] = &rows[i]
+		}
+
+		err = s.attachRelationsBatch(ctx, models, *include, depth)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	return reflect.ValueOf(rows), nil
+}
+
+// DefineRelation declares a relation that is loaded automatically when the matching
+// includeT field is set. Defining a relation again with the same name replaces it.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DefineRelation(relation Relation) {
+	if relation.Field == "" {
+		relation.Field = relation.Name
+	}
+
+	index := slices.IndexFunc(s.relations, func(defined Relation) bool {
+		return defined.Name == relation.Name
+	})
+
+	if index >= 0 {
+		s.relations[index] = relation
+	} else {
+		s.relations = append(s.relations, relation)
+	}
+
+	util.RegisterWhereRelation(reflect.TypeFor[whereT](), relation.Name, relationExists{
+		parentTable: s.tableName,
+		relation:    relation,
+	})
+}
+
+// relationExists builds the EXISTS subqueries used by relation filters on where structs
+type relationExists struct {
+	parentTable string
+	relation    Relation
+}
+
+func (r relationExists) ModelField() string {
+	return r.relation.Field
+}
+
+func (r relationExists) ExistsSQL(where string) string {
+	targetTable := r.relation.Target.relationTableName()
+
+	switch r.relation.Kind {
+	case RelationBelongsTo:
+		return "EXISTS (SELECT 1 FROM " + targetTable + " WHERE " + targetTable + ".id = " + r.parentTable + "." + r.relation.ForeignKey + andWhere(where) + ")"
+	case RelationHasMany:
+		return "EXISTS (SELECT 1 FROM " + targetTable + " WHERE " + targetTable + "." + r.relation.ForeignKey + " = " + r.parentTable + ".id" + andWhere(where) + ")"
+	case RelationManyToMany:
+		// filter the targets in their own subquery so columns shared with the join table are not ambiguous
+		joinTable := r.relation.JoinTable
+		targetIds := "SELECT id FROM " + targetTable
+		if where != "" {
+			targetIds += " WHERE " + where
+		}
+
+		return "EXISTS (SELECT 1 FROM " + joinTable + " WHERE " + joinTable + "." + r.relation.JoinForeignKey + " = " + r.parentTable + ".id AND " + joinTable + "." + r.relation.JoinTargetKey + " IN (" + targetIds + "))"
+	}
+
+	return "FALSE"
+}
+
+func andWhere(where string) string {
+	if where == "" {
+		return ""
+	}
+
+	return " AND (" + where + ")"
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) attachDeclaredRelations(ctx context.Context, models []*modelT, include includeT, depth int) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	includeValue := reflect.ValueOf(include)
+
+	for _, relation := range s.relations {
+		query, ok, err := includedRelationQuery(includeValue.FieldByName(relation.Name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if depth >= maxIncludeDepth {
+			return validationError(fmt.Sprintf("includes are nested deeper than %d levels at relation %s", maxIncludeDepth, relation.Name))
+		}
+
+		switch relation.Kind {
+		case RelationBelongsTo:
+			err = attachBelongsTo(ctx, relation, models, query, depth+1)
+		case RelationHasMany:
+			err = attachHasMany(ctx, relation, models, query, depth+1)
+		case RelationManyToMany:
+			err = attachManyToMany(ctx, s.db, relation, models, query, depth+1)
+		default:
+			err = errors.New("unknown relation kind for " + relation.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// includedRelationQuery reads an include field, which is either a bool flag or an *Include
+func includedRelationQuery(field reflect.Value) (relationQuery, bool, error) {
+	if !field.IsValid() {
+		return relationQuery{}, false, nil
+	}
+
+	if field.Kind() == reflect.Bool {
+		return relationQuery{}, field.Bool(), nil
+	}
+
+	if field.Kind() == reflect.Pointer && !field.IsNil() {
+		if includer, ok := field.Interface().(relationIncluder); ok {
+			query, err := includer.relationQuery()
+			return query, err == nil, err
+		}
+	}
+
+	return relationQuery{}, false, nil
+}
+
+func attachBelongsTo[modelT any](ctx context.Context, relation Relation, models []*modelT, query relationQuery, depth int) error {
+	foreignKeys, err := columnKeys(models, relation.ForeignKey)
+	if err != nil {
+		return err
+	}
+
+	// a parent belongs to a single row so the limit does not apply
+	query.limit = nil
+
+	targets, err := relation.Target.loadRelated(ctx, "id", uniqueValues(foreignKeys), query, depth)
+	if err != nil {
+		return err
+	}
+
+	targetsById, err := indexRows(targets, "id")
+	if err != nil {
+		return err
+	}
+
+	for i, model := range models {
+		field, err := relationField(model, relation)
+		if err != nil {
+			return err
+		}
+
+		matches := targetsById[relationKey(foreignKeys[i])]
+		if len(matches) > 0 {
+			field.Set(matches[0].Addr())
+		}
+	}
+
+	return nil
+}
+
+func attachHasMany[modelT any](ctx context.Context, relation Relation, models []*modelT, query relationQuery, depth int) error {
+	ids, err := columnKeys(models, "id")
+	if err != nil {
+		return err
+	}
+
+	targets, err := relation.Target.loadRelated(ctx, relation.ForeignKey, uniqueValues(ids), query, depth)
+	if err != nil {
+		return err
+	}
+
+	targetsByForeignKey, err := indexRows(targets, relation.ForeignKey)
+	if err != nil {
+		return err
+	}
+
+	for i, model := range models {
+		field, err := relationField(model, relation)
+		if err != nil {
+			return err
+		}
+
+		setRelatedSlice(field, targetsByForeignKey[relationKey(ids[i])])
+	}
+
+	return nil
+}
+
+func attachManyToMany[modelT any](ctx context.Context, db util.Querier, relation Relation, models []*modelT, query relationQuery, depth int) error {
+	ids, err := columnKeys(models, "id")
+	if err != nil {
+		return err
+	}
+
+	uniqueIds := uniqueValues(ids)
+	targetIdsBySource := map[string][]any{}
+	targetIds := []any{}
+
+	for start := 0; start < len(uniqueIds); start += maxPlaceholders {
+		chunk := uniqueIds[start:min(start+maxPlaceholders, len(uniqueIds))]
+
+		paramPlaceholders := strings.Repeat("?, ", len(chunk))
+		paramPlaceholders = paramPlaceholders[:len(paramPlaceholders)-2]
+
+		sql := "SELECT " + relation.JoinForeignKey + ", " + relation.JoinTargetKey + " FROM " + relation.JoinTable + " WHERE " + relation.JoinForeignKey + " IN (" + paramPlaceholders + ")"
+
+		rows, err := db.QueryContext(ctx, sql, chunk...)
+		if err != nil {
+			return translateError(err)
+		}
+
+		for rows.Next() {
+			var sourceId, targetId any
+			if err := rows.Scan(&sourceId, &targetId); err != nil {
+				rows.Close()
+				return err
+			}
+
+			targetIdsBySource[relationKey(sourceId)] = append(targetIdsBySource[relationKey(sourceId)], targetId)
+			targetIds = append(targetIds, targetId)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	// targets are shared between parents, so the limit is applied per parent once they are loaded
+	limit := query.limit
+	query.limit = nil
+
+	targets, err := relation.Target.loadRelated(ctx, "id", uniqueValues(targetIds), query, depth)
+	if err != nil {
+		return err
+	}
+
+	targetPositions := map[string]int{}
+	for i := 0; i < targets.Len(); i++ {
+		values, err := util.GetColumnValues(targets.Index(i).Addr().Interface(), []string{"id"})
+		if err != nil {
+			return err
+		}
+		targetPositions[relationKey(values[0])] = i
+	}
+
+	for i, model := range models {
+		field, err := relationField(model, relation)
+		if err != nil {
+			return err
+		}
+
+		// keep the target query's ordering
+		positions := []int{}
+		for _, targetId := range targetIdsBySource[relationKey(ids[i])] {
+			if position, ok := targetPositions[relationKey(targetId)]; ok {
+				positions = append(positions, position)
+			}
+		}
+		slices.Sort(positions)
+
+		if limit != nil && len(positions) > *limit {
+			positions = positions[:*limit]
+		}
+
+		related := []reflect.Value{}
+		for _, position := range positions {
+			related = append(related, targets.Index(position))
+		}
+
+		setRelatedSlice(field, related)
+	}
+
+	return nil
+}
+
+func columnKeys[modelT any](models []*modelT, column string) ([]any, error) {
+	keys := []any{}
+	for _, model := range models {
+		values, err := util.GetColumnValues(model, []string{column})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, values[0])
+	}
+
+	return keys, nil
+}
+
+// indexRows groups the elements of a slice of models by the value of column
+func indexRows(rows reflect.Value, column string) (map[string][]reflect.Value, error) {
+	index := map[string][]reflect.Value{}
+
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+
+		values, err := util.GetColumnValues(row.Addr().Interface(), []string{column})
+		if err != nil {
+			return nil, err
+		}
+
+		key := relationKey(values[0])
+		index[key] = append(index[key], row)
+	}
+
+	return index, nil
+}
+
+func relationField[modelT any](model *modelT, relation Relation) (reflect.Value, error) {
+	field := reflect.ValueOf(model).Elem().FieldByName(relation.Field)
+
+	if !field.IsValid() || !field.CanSet() {
+		return reflect.Value{}, errors.New("model has no settable field " + relation.Field + " for relation " + relation.Name)
+	}
+
+	return field, nil
+}
+
+// setRelatedSlice stores rows in a *[]T field
+func setRelatedSlice(field reflect.Value, rows []reflect.Value) {
+	slice := reflect.MakeSlice(field.Type().Elem(), 0, len(rows))
+	for _, row := range rows {
+		slice = reflect.Append(slice, row)
+	}
+
+	pointer := reflect.New(slice.Type())
+	pointer.Elem().Set(slice)
+	field.Set(pointer)
+}
+
+// relationKey normalizes key values so ids read from struct fields and ids scanned by the
+// driver compare equal
+func relationKey(value any) string {
+	if bytes, ok := value.([]byte); ok {
+		return string(bytes)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func uniqueValues(values []any) []any {
+	seen := map[string]bool{}
+	unique := []any{}
+
+	for _, value := range values {
+		key := relationKey(value)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
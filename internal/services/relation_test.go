@@ -0,0 +1,355 @@
+package services
+
+import (
+	"database/sql/driver"
+	"slices"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/models"
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+var (
+	userColumns  = []string{"id", "email", "passwordHash", "settings", "createdAt", "updatedAt"}
+	eventColumns = []string{"id", "ownerUserId", "label", "coverPhotoPath", "metadata", "createdAt", "updatedAt"}
+)
+
+func userRow(id int64) []driver.Value {
+	return []driver.Value{id, []byte("user@example.com"), []byte("hash"), nil, []byte("2024-01-01"), []byte("2024-01-01")}
+}
+
+func eventRow(id int64, ownerUserId int64) []driver.Value {
+	return []driver.Value{id, ownerUserId, []byte("event"), nil, nil, []byte("2024-01-01"), []byte("2024-01-01")}
+}
+
+// answerTables answers a query with the rows of the first table it selects from, so tables
+// only referenced by subqueries are not mistaken for it
+func answerTables(users [][]driver.Value, events [][]driver.Value) func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		usersAt := strings.Index(query, " FROM users")
+		eventsAt := strings.Index(query, " FROM events")
+
+		if usersAt >= 0 && (eventsAt < 0 || usersAt < eventsAt) {
+			return userColumns, users, nil
+		}
+		return eventColumns, events, nil
+	}
+}
+
+// newRelatedServices sets up a user and an event service sharing a stub database
+func newRelatedServices(t *testing.T) (*UserService, *EventService, *stubdb.DB) {
+	db, stub := stubdb.Open(t)
+
+	userService := NewUserService(db, nil)
+	eventService := NewEventService(db, userService)
+	userService.SetEventService(eventService)
+
+	return userService, eventService, stub
+}
+
+func TestIncludesLoadInOneQuery(t *testing.T) {
+	userService, _, stub := newRelatedServices(t)
+	stub.Query = answerTables(
+		[][]driver.Value{userRow(1), userRow(2), userRow(3)},
+		[][]driver.Value{eventRow(10, 1), eventRow(11, 3), eventRow(12, 1)},
+	)
+
+	users, err := userService.GetMany(WhereUser{}, &IncludeWithUser{Events: &Include[WhereEvent, IncludeWithEvent]{}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := stub.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected a query for users and one for their events, got %q", stub.Log())
+	}
+
+	if want := "SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM events WHERE ownerUserId IN (?, ?, ?)"; statements[1].Query != want {
+		t.Errorf("events query = %q, want %q", statements[1].Query, want)
+	}
+
+	if want := []driver.Value{int64(1), int64(2), int64(3)}; !slices.Equal(statements[1].Args, want) {
+		t.Errorf("events args = %v, want %v", statements[1].Args, want)
+	}
+
+	eventIds := map[int][]int{}
+	for _, user := range *users {
+		if user.Events == nil {
+			t.Fatalf("user %d has no events slice", user.Id)
+		}
+		eventIds[user.Id] = []int{}
+		for _, event := range *user.Events {
+			eventIds[user.Id] = append(eventIds[user.Id], event.Id)
+		}
+	}
+
+	if !slices.Equal(eventIds[1], []int{10, 12}) || len(eventIds[2]) != 0 || !slices.Equal(eventIds[3], []int{11}) {
+		t.Errorf("events stitched to the wrong users: %v", eventIds)
+	}
+}
+
+func TestIncludeOwnersDeduplicatesIds(t *testing.T) {
+	_, eventService, stub := newRelatedServices(t)
+	stub.Query = answerTables(
+		[][]driver.Value{userRow(2), userRow(1)},
+		[][]driver.Value{eventRow(10, 1), eventRow(11, 2), eventRow(12, 1)},
+	)
+
+	events, err := eventService.GetMany(WhereEvent{}, &IncludeWithEvent{User: &Include[WhereUser, IncludeWithUser]{}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := stub.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected a query for events and one for their owners, got %q", stub.Log())
+	}
+
+	if want := "SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users WHERE id IN (?, ?)"; statements[1].Query != want {
+		t.Errorf("users query = %q, want %q", statements[1].Query, want)
+	}
+
+	if want := []driver.Value{int64(1), int64(2)}; !slices.Equal(statements[1].Args, want) {
+		t.Errorf("users args = %v, want %v", statements[1].Args, want)
+	}
+
+	for _, event := range *events {
+		if event.Owner == nil || event.Owner.Id != event.OwnerUserId {
+			t.Errorf("event %d has owner %+v, want user %d", event.Id, event.Owner, event.OwnerUserId)
+		}
+	}
+}
+
+func TestIncludeOnSingleModel(t *testing.T) {
+	userService, _, stub := newRelatedServices(t)
+	stub.Query = answerTables([][]driver.Value{userRow(1)}, [][]driver.Value{eventRow(10, 1)})
+
+	user, err := userService.GetOneById(1, &IncludeWithUser{Events: &Include[WhereEvent, IncludeWithEvent]{}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user.Events == nil || !slices.EqualFunc(*user.Events, []models.Event{{Id: 10}}, func(a, b models.Event) bool { return a.Id == b.Id }) {
+		t.Errorf("events = %+v", user.Events)
+	}
+
+	want := []string{
+		"SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users WHERE id=? LIMIT 1",
+		"SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM events WHERE ownerUserId IN (?)",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+}
+
+func TestIncludeLimitPerParent(t *testing.T) {
+	userService, _, stub := newRelatedServices(t)
+	stub.Query = answerTables(
+		[][]driver.Value{userRow(1), userRow(2)},
+		[][]driver.Value{eventRow(12, 1), eventRow(11, 2), eventRow(10, 1)},
+	)
+
+	users, err := userService.GetMany(WhereUser{}, &IncludeWithUser{Events: &Include[WhereEvent, IncludeWithEvent]{
+		Where:   &WhereEvent{OwnerUserId: filters.IntEquals(1)},
+		OrderBy: []OrderBy{{Column: "id", Direction: SortDescending}},
+		Limit:   util.ToPointer(2),
+	}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := stub.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected a query for users and one for their events, got %q", stub.Log())
+	}
+
+	// the limit applies to each user rather than to the events query as a whole
+	want := "SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM (" +
+		"SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt, ROW_NUMBER() OVER (PARTITION BY ownerUserId ORDER BY id DESC) AS relationRowNumber" +
+		" FROM events WHERE ownerUserId IN (?, ?) AND (ownerUserId = ?)" +
+		") ranked WHERE relationRowNumber <= ? ORDER BY id DESC"
+	if statements[1].Query != want {
+		t.Errorf("events query = %q, want %q", statements[1].Query, want)
+	}
+
+	if want := []driver.Value{int64(1), int64(2), "1", int64(2)}; !slices.Equal(statements[1].Args, want) {
+		t.Errorf("events args = %v, want %v", statements[1].Args, want)
+	}
+
+	// rows keep the order of the query within each parent
+	eventIds := map[int][]int{}
+	for _, user := range *users {
+		for _, event := range *user.Events {
+			eventIds[user.Id] = append(eventIds[user.Id], event.Id)
+		}
+	}
+
+	if !slices.Equal(eventIds[1], []int{12, 10}) || !slices.Equal(eventIds[2], []int{11}) {
+		t.Errorf("events stitched to the wrong users: %v", eventIds)
+	}
+}
+
+func TestNestedIncludes(t *testing.T) {
+	userService, _, stub := newRelatedServices(t)
+	stub.Query = answerTables(
+		[][]driver.Value{userRow(1), userRow(2)},
+		[][]driver.Value{eventRow(10, 2)},
+	)
+
+	users, err := userService.GetMany(WhereUser{}, &IncludeWithUser{Events: &Include[WhereEvent, IncludeWithEvent]{
+		Include: &IncludeWithEvent{User: &Include[WhereUser, IncludeWithUser]{}},
+	}})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users",
+		"SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM events WHERE ownerUserId IN (?, ?)",
+		"SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users WHERE id IN (?)",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+
+	user := (*users)[1]
+	if len(*user.Events) != 1 || (*user.Events)[0].Owner == nil || (*user.Events)[0].Owner.Id != 2 {
+		t.Errorf("nested owner not attached: %+v", *user.Events)
+	}
+}
+
+// nestedIncludes includes events and their owners in turn until levels relations are nested
+func nestedIncludes(levels int) *IncludeWithUser {
+	include := &IncludeWithUser{}
+	users := include
+	var events *IncludeWithEvent
+
+	for level := 1; level <= levels; level++ {
+		if level%2 == 1 {
+			events = &IncludeWithEvent{}
+			users.Events = &Include[WhereEvent, IncludeWithEvent]{Include: events}
+		} else {
+			users = &IncludeWithUser{}
+			events.User = &Include[WhereUser, IncludeWithUser]{Include: users}
+		}
+	}
+
+	return include
+}
+
+func TestIncludeDepthLimit(t *testing.T) {
+	userService, _, stub := newRelatedServices(t)
+	stub.Query = answerTables([][]driver.Value{userRow(1)}, [][]driver.Value{eventRow(10, 1)})
+
+	if _, err := userService.GetMany(WhereUser{}, nestedIncludes(maxIncludeDepth)); err != nil {
+		t.Fatalf("expected %d nested includes to load, got %v", maxIncludeDepth, err)
+	}
+
+	// one query for the users and one for each level
+	if log := stub.Log(); len(log) != maxIncludeDepth+1 {
+		t.Errorf("expected %d statements, got %q", maxIncludeDepth+1, log)
+	}
+
+	_, err := userService.GetMany(WhereUser{}, nestedIncludes(maxIncludeDepth+1))
+
+	if want := "includes are nested deeper than 5 levels at relation User"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestServicesWithoutDependencies(t *testing.T) {
+	eventService := NewEventService(nil, nil)
+
+	if len(eventService.relations) != 0 {
+		t.Errorf("expected no relations without a user service, got %d", len(eventService.relations))
+	}
+
+	userService := NewUserService(nil, nil)
+	eventService.SetUserService(userService)
+
+	if len(eventService.relations) != 2 {
+		t.Errorf("expected 2 relations after SetUserService, got %d", len(eventService.relations))
+	}
+}
+
+func TestDefineRelationReplacesByName(t *testing.T) {
+	userService := NewUserService(nil, nil)
+	eventService := NewEventService(nil, userService)
+
+	userService.SetEventService(eventService)
+	userService.SetEventService(eventService)
+
+	names := []string{}
+	for _, relation := range userService.relations {
+		names = append(names, relation.Name)
+	}
+
+	if len(names) != 2 || names[0] != "Events" || names[1] != "AttendingEvents" {
+		t.Errorf("expected [Events AttendingEvents], got %v", names)
+	}
+}
+
+func TestRelationFilters(t *testing.T) {
+	userService, eventService, stub := newRelatedServices(t)
+	stub.Query = answerTables([][]driver.Value{userRow(1)}, [][]driver.Value{eventRow(10, 1)})
+
+	users, err := userService.GetMany(WhereUser{Events: filters.RelationSome(WhereEvent{})}, nil)
+
+	if err != nil || len(*users) != 1 {
+		t.Fatalf("Some = %v, %v", users, err)
+	}
+
+	_, err = userService.GetMany(WhereUser{Events: filters.RelationEvery(WhereEvent{OwnerUserId: filters.IntEquals(1)})}, nil)
+	if err != nil {
+		t.Fatalf("Every: unexpected error: %v", err)
+	}
+
+	events, err := eventService.GetMany(WhereEvent{User: filters.RelationNone(WhereUser{Email: filters.StrEquals("a@example.com")})}, nil)
+
+	if err != nil || len(*events) != 1 || (*events)[0].Id != 10 {
+		t.Fatalf("None = %v, %v", events, err)
+	}
+
+	_, err = eventService.GetMany(WhereEvent{Attendees: filters.RelationSome(WhereUser{Email: filters.StrEquals("b@example.com")})}, nil)
+	if err != nil {
+		t.Fatalf("many to many Some: unexpected error: %v", err)
+	}
+
+	statements := stub.Statements()
+	want := []stubdb.Statement{
+		{
+			Query: "SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users WHERE (EXISTS (SELECT 1 FROM events WHERE events.ownerUserId = users.id))",
+			Args:  []driver.Value{},
+		},
+		{
+			Query: "SELECT id, email, passwordHash, settings, createdAt, updatedAt FROM users WHERE (NOT EXISTS (SELECT 1 FROM events WHERE events.ownerUserId = users.id AND (NOT (ownerUserId = ?))))",
+			Args:  []driver.Value{"1"},
+		},
+		{
+			Query: "SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM events WHERE (NOT EXISTS (SELECT 1 FROM users WHERE users.id = events.ownerUserId AND (email = ?)))",
+			Args:  []driver.Value{"a@example.com"},
+		},
+		{
+			Query: "SELECT id, ownerUserId, label, coverPhotoPath, metadata, createdAt, updatedAt FROM events WHERE (EXISTS (SELECT 1 FROM event_attendees WHERE event_attendees.eventId = events.id AND event_attendees.userId IN (SELECT id FROM users WHERE email = ?)))",
+			Args:  []driver.Value{"b@example.com"},
+		},
+	}
+
+	if len(statements) != len(want) {
+		t.Fatalf("statements = %q", stub.Log())
+	}
+
+	for i := range want {
+		if statements[i].Query != want[i].Query || !slices.Equal(statements[i].Args, want[i].Args) {
+			t.Errorf("statement %d = %q %v, want %q %v", i, statements[i].Query, statements[i].Args, want[i].Query, want[i].Args)
+		}
+	}
+}
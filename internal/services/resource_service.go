@@ -1,9 +1,9 @@
 package services
 
 import (
-	"database/sql"
-	"errors"
+	"context"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"smithsolutions/go-api/internal/util"
@@ -17,13 +17,16 @@ const (
 )
 
 type GetOneOverrider[modelT, includeT any] interface {
-	GetOneById(id int, include *includeT) (*modelT, error)
+	GetOneByIdContext(ctx context.Context, id int, include *includeT) (*modelT, error)
 }
 type GetManyOverrider[modelT, whereT, includeT any] interface {
-	GetMany(where whereT, include *includeT) (*[]modelT, error)
+	GetManyContext(ctx context.Context, where whereT, include *includeT) (*[]modelT, error)
 }
 type AttachRelationsOverrider[modelT, includeT any] interface {
-	AttachRelations(model *modelT, include includeT) error
+	AttachRelationsContext(ctx context.Context, model *modelT, include includeT) error
+}
+type AttachRelationsBatchOverrider[modelT, includeT any] interface {
+	AttachRelationsBatchContext(ctx context.Context, models []*modelT, include includeT) error
 }
 
 type Creater interface {
@@ -39,18 +42,22 @@ type Wherer interface {
 }
 
 type ResourceService[modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any] struct {
-	tableName string
-	db        *sql.DB
-	columns   []string
+	tableName   string
+	db          util.Querier
+	columns     []string
+	jsonColumns []string
 
 	status ServiceStatus
 
-	getOneOverrider          GetOneOverrider[modelT, includeT]
-	getManyOverrider         GetManyOverrider[modelT, whereT, includeT]
-	attachRelationsOverrider AttachRelationsOverrider[modelT, includeT]
+	getOneOverrider               GetOneOverrider[modelT, includeT]
+	getManyOverrider              GetManyOverrider[modelT, whereT, includeT]
+	attachRelationsOverrider      AttachRelationsOverrider[modelT, includeT]
+	attachRelationsBatchOverrider AttachRelationsBatchOverrider[modelT, includeT]
+
+	relations []Relation
 }
 
-func SetupResourceService[modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](db *sql.DB, tableName string, model any) ResourceService[modelT, createT, updateT, whereT, includeT] {
+func SetupResourceService[modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](db util.Querier, tableName string, model any) ResourceService[modelT, createT, updateT, whereT, includeT] {
 	columns, err := util.GetColumnsFromModel(model)
 
 	status := ServiceStatusRunning
@@ -60,67 +67,94 @@ func SetupResourceService[modelT any, createT Creater, updateT Updater, whereT W
 	}
 
 	return ResourceService[modelT, createT, updateT, whereT, includeT]{
-		tableName: tableName,
-		db:        db,
-		columns:   columns,
-		status:    status,
+		tableName:   tableName,
+		db:          db,
+		columns:     columns,
+		jsonColumns: util.GetJSONColumnsFromModel(model),
+		status:      status,
 	}
 }
 
 func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) Create(data createT) (int, error) {
+	return s.CreateContext(context.Background(), data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CreateContext(ctx context.Context, data createT) (int, error) {
 	// TODO: add overrider switch logic
 
 	if s.status == ServiceStatusFailed {
-		return 0, errors.New("service failed to setup or is currently in failed state")
+		return 0, ErrServiceFailed
+	}
+
+	if err := validateInput(data); err != nil {
+		return 0, err
 	}
 
 	columns, params, err := data.SQL()
 
 	if err != nil {
-		return 0, err
+		return 0, invalidInput(err)
 	}
 
 	if len(params) <= 0 {
-		return 0, errors.New("no values provided for insert statement")
+		return 0, validationError("no values provided for insert statement")
 	}
 
 	paramPlaceholders := strings.Repeat("?, ", len(params))
 	paramPlaceholders = paramPlaceholders[:len(paramPlaceholders)-2]
 
 	sql := "INSERT INTO " + s.tableName + " (" + strings.Join(columns, ",") + ") VALUES (" + paramPlaceholders + ")"
-	result, err := s.db.Exec(sql, params...)
+	result, err := s.db.ExecContext(ctx, sql, params...)
 
 	if err != nil {
-		return 0, err
+		return 0, s.translateWriteError(err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	insertId, err := result.LastInsertId()
 
-	return int(rowsAffected), err
+	return int(insertId), err
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CreateAndGet(data createT, include *includeT) (*modelT, error) {
+	return s.CreateAndGetContext(context.Background(), data, include)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CreateAndGetContext(ctx context.Context, data createT, include *includeT) (*modelT, error) {
+	id, err := s.CreateContext(ctx, data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOneByIdContext(ctx, id, include)
 }
 
 func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetOneById(id int, include *includeT) (*modelT, error) {
+	return s.GetOneByIdContext(context.Background(), id, include)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetOneByIdContext(ctx context.Context, id int, include *includeT) (*modelT, error) {
 	if s.getOneOverrider != nil {
-		return s.getOneOverrider.GetOneById(id, include)
+		return s.getOneOverrider.GetOneByIdContext(ctx, id, include)
 	}
 
 	if s.status == ServiceStatusFailed {
-		return nil, errors.New("service failed to setup or is currently in failed state")
+		return nil, ErrServiceFailed
 	}
 
 	sql := "SELECT " + strings.Join(s.columns, ", ") + " FROM " + s.tableName + " WHERE id=? LIMIT 1"
 	params := []any{id}
 
 	var row modelT
-	err := util.ScanRow(s.db, &row, sql, params...)
+	err := util.ScanRowContext(ctx, s.db, &row, sql, params...)
 
 	if err != nil {
 
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	if include != nil {
-		err := s.AttachRelations(&row, *include)
+		err := s.AttachRelationsContext(ctx, &row, *include)
 		if err != nil {
 			return nil, err
 		}
@@ -130,69 +164,214 @@ func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetOneById
 }
 
 func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetMany(where whereT, include *includeT) (*[]modelT, error) {
+	return s.GetManyContext(context.Background(), where, include)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetManyContext(ctx context.Context, where whereT, include *includeT) (*[]modelT, error) {
 	if s.getManyOverrider != nil {
-		return s.getManyOverrider.GetMany(where, include)
+		return s.getManyOverrider.GetManyContext(ctx, where, include)
+	}
+
+	rows, _, err := s.GetManyWithOptionsContext(ctx, where, include, QueryOptions{})
+
+	return rows, err
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetManyWithOptions(where whereT, include *includeT, options QueryOptions) (*[]modelT, *PageInfo, error) {
+	return s.GetManyWithOptionsContext(context.Background(), where, include, options)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GetManyWithOptionsContext(ctx context.Context, where whereT, include *includeT, options QueryOptions) (*[]modelT, *PageInfo, error) {
+	if s.status == ServiceStatusFailed {
+		return nil, nil, ErrServiceFailed
 	}
 
 	whereString, params, err := where.SQL()
 
 	if err != nil {
-		return nil, err
+		return nil, nil, invalidInput(err)
+	}
+
+	var cursorOrderBy []OrderBy
+	seekString := ""
+	seekParams := []any{}
+
+	if options.UseCursor {
+		if options.Limit == nil {
+			return nil, nil, validationError("cursor pagination requires a limit")
+		}
+		if options.Offset != nil {
+			return nil, nil, validationError("cursor pagination cannot be combined with an offset")
+		}
+
+		cursorOrderBy = options.cursorOrderBy()
+		options.OrderBy = cursorOrderBy
+
+		for _, ordering := range cursorOrderBy {
+			if slices.Contains(s.jsonColumns, ordering.Column) {
+				return nil, nil, validationError("cannot use cursor pagination ordered by JSON column " + ordering.Column)
+			}
+		}
+
+		if options.After != nil {
+			values, err := decodeCursor(*options.After, cursorOrderBy)
+			if err != nil {
+				return nil, nil, err
+			}
+			seekString, seekParams = seekSQL(cursorOrderBy, values)
+		}
 	}
 
 	if whereString != "" {
 		whereString = " WHERE " + whereString
 	}
 
-	sql := "SELECT " + strings.Join(s.columns, ", ") + " FROM " + s.tableName + whereString
+	orderByString, err := options.orderBySQL(s.columns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limitString, err := options.limitSQL()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pageInfo := &PageInfo{
+		Limit:  options.Limit,
+		Offset: options.Offset,
+	}
+
+	if options.WithTotal {
+		var total int
+		err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+s.tableName+whereString, params...).Scan(&total)
+		if err != nil {
+			return nil, nil, translateError(err)
+		}
+		pageInfo.Total = &total
+	}
+
+	if seekString != "" {
+		if whereString == "" {
+			whereString = " WHERE " + seekString
+		} else {
+			whereString = " WHERE (" + strings.TrimPrefix(whereString, " WHERE ") + ") AND " + seekString
+		}
+		params = append(params, seekParams...)
+	}
+
+	if options.UseCursor {
+		// fetch one extra row to find out if there is a next page
+		limitString, _ = QueryOptions{Limit: util.ToPointer(*options.Limit + 1)}.limitSQL()
+	}
+
+	sql := "SELECT " + strings.Join(s.columns, ", ") + " FROM " + s.tableName + whereString + orderByString + limitString
 
 	var rows []modelT
-	err = util.ScanRows(s.db, &rows, sql, params...)
+	err = util.ScanRowsContext(ctx, s.db, &rows, sql, params...)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, translateError(err)
 	}
 
-	for _, row := range rows {
-		if include != nil {
-			err = s.AttachRelations(&row, *include)
-			if err != nil {
-				return nil, err
-			}
+	if options.UseCursor && len(rows) > *options.Limit {
+		rows = rows[:*options.Limit]
+
+		columns := []string{}
+		for _, ordering := range cursorOrderBy {
+			columns = append(columns, ordering.Column)
+		}
+
+		values, err := util.GetColumnValues(&rows[len(rows)-1], columns)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		nextCursor, err := encodeCursor(cursorOrderBy, values)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageInfo.NextCursor = &nextCursor
+	}
+
+	if include != nil && len(rows) > 0 {
+		models := make([]*modelT, len(rows))
+		for i := range rows {
+			models[i] = &rows[i]
+		}
+
+		err = s.AttachRelationsBatchContext(ctx, models, *include)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
 	if rows == nil {
-		return &([]modelT{}), nil
+		return &([]modelT{}), pageInfo, nil
 	}
 
-	return &rows, nil
+	return &rows, pageInfo, nil
 }
 
 func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateOne(id int, data updateT) (int, error) {
+	return s.UpdateOneContext(context.Background(), id, data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateOneContext(ctx context.Context, id int, data updateT) (int, error) {
 	// TODO: add overrider switch logic
 
 	if s.status == ServiceStatusFailed {
-		return 0, errors.New("service failed to setup or is currently in failed state")
+		return 0, ErrServiceFailed
+	}
+
+	if err := validateInput(data); err != nil {
+		return 0, err
 	}
 
 	setString, params, err := data.SQL()
 
 	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	return s.updateOne(ctx, id, setString, params)
+}
+
+// UpdateOneFields only writes the named fields of data, given as Go field names, and leaves the
+// other columns of the row as they are
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateOneFields(id int, data updateT, fields []string) (int, error) {
+	return s.UpdateOneFieldsContext(context.Background(), id, data, fields)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateOneFieldsContext(ctx context.Context, id int, data updateT, fields []string) (int, error) {
+	if s.status == ServiceStatusFailed {
+		return 0, ErrServiceFailed
+	}
+
+	if err := validateInputFields(data, fields); err != nil {
 		return 0, err
 	}
 
-	params = append(params, id)
+	setString, params, err := util.GetUpdateFieldsSQL(data, fields)
 
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	return s.updateOne(ctx, id, setString, params)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) updateOne(ctx context.Context, id int, setString string, params []any) (int, error) {
 	if len(params) <= 0 {
-		return 0, errors.New("no values provided for update statement")
+		return 0, validationError("no values provided for update statement")
 	}
 
+	params = append(params, id)
+
 	sql := "UPDATE " + s.tableName + " SET " + setString + " WHERE id=? LIMIT 1"
-	result, err := s.db.Exec(sql, params...)
+	result, err := s.db.ExecContext(ctx, sql, params...)
 
 	if err != nil {
-		return 0, err
+		return 0, s.translateWriteError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -201,19 +380,23 @@ func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateOne(
 }
 
 func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteOneById(id int) (int, error) {
+	return s.DeleteOneByIdContext(context.Background(), id)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteOneByIdContext(ctx context.Context, id int) (int, error) {
 	// TODO: add overrider switch logic
 
 	if s.status == ServiceStatusFailed {
-		return 0, errors.New("service failed to setup or is currently in failed state")
+		return 0, ErrServiceFailed
 	}
 
 	sql := "DELETE FROM " + s.tableName + " WHERE id =? LIMIT 1"
 	params := []any{id}
 
-	result, err := s.db.Exec(sql, params...)
+	result, err := s.db.ExecContext(ctx, sql, params...)
 
 	if err != nil {
-		return 0, err
+		return 0, translateError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -221,11 +404,101 @@ func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteOneB
 	return int(rowsAffected), err
 }
 
-func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AttachRelations(user *modelT, include includeT) error {
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AttachRelations(model *modelT, include includeT) error {
+	return s.AttachRelationsContext(context.Background(), model, include)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AttachRelationsContext(ctx context.Context, model *modelT, include includeT) error {
+	if s.attachRelationsOverrider != nil {
+
+		return s.attachRelationsOverrider.AttachRelationsContext(ctx, model, include)
+	}
+
+	return s.attachDeclaredRelations(ctx, []*modelT{model}, include, 0)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AttachRelationsBatch(models []*modelT, include includeT) error {
+	return s.AttachRelationsBatchContext(context.Background(), models, include)
+}
+
+// AttachRelationsBatchContext attaches relations to every model at once so each relation is
+// loaded with a single query, falling back to the per model overrider when one is set
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AttachRelationsBatchContext(ctx context.Context, models []*modelT, include includeT) error {
+	return s.attachRelationsBatch(ctx, models, include, 0)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) attachRelationsBatch(ctx context.Context, models []*modelT, include includeT, depth int) error {
+	if s.attachRelationsBatchOverrider != nil {
+		return s.attachRelationsBatchOverrider.AttachRelationsBatchContext(ctx, models, include)
+	}
+
 	if s.attachRelationsOverrider != nil {
+		for _, model := range models {
+			err := s.attachRelationsOverrider.AttachRelationsContext(ctx, model, include)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return s.attachDeclaredRelations(ctx, models, include, depth)
+}
 
-		return s.attachRelationsOverrider.AttachRelations(user, include)
+// getManyWhereIn loads every row whose column matches one of values, used to batch load relations.
+// A limit on the query is applied to each distinct value of column rather than the whole result.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) getManyWhereIn(ctx context.Context, column string, values []any, query relationQuery) ([]modelT, error) {
+	if s.status == ServiceStatusFailed {
+		return nil, ErrServiceFailed
+	}
+
+	orderByString, err := QueryOptions{OrderBy: query.orderBy}.orderBySQL(s.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.limit != nil && *query.limit < 0 {
+		return nil, validationError("limit cannot be negative")
+	}
+
+	rows := []modelT{}
+
+	for start := 0; start < len(values); start += maxPlaceholders {
+		chunk := values[start:min(start+maxPlaceholders, len(values))]
+
+		paramPlaceholders := strings.Repeat("?, ", len(chunk))
+		paramPlaceholders = paramPlaceholders[:len(paramPlaceholders)-2]
+
+		whereString := column + " IN (" + paramPlaceholders + ")"
+		params := append([]any{}, chunk...)
+
+		if query.where != "" {
+			whereString += " AND (" + query.where + ")"
+			params = append(params, query.params...)
+		}
+
+		columns := strings.Join(s.columns, ", ")
+		sql := "SELECT " + columns + " FROM " + s.tableName + " WHERE " + whereString + orderByString
+
+		if query.limit != nil {
+			windowOrder := strings.TrimPrefix(orderByString, " ORDER BY ")
+			if windowOrder == "" {
+				windowOrder = "id"
+			}
+
+			sql = "SELECT " + columns + " FROM (" +
+				"SELECT " + columns + ", ROW_NUMBER() OVER (PARTITION BY " + column + " ORDER BY " + windowOrder + ") AS relationRowNumber" +
+				" FROM " + s.tableName + " WHERE " + whereString +
+				") ranked WHERE relationRowNumber <= ?" + orderByString
+			params = append(params, *query.limit)
+		}
+
+		err := util.ScanRowsContext(ctx, s.db, &rows, sql, params...)
+		if err != nil {
+			return nil, translateError(err)
+		}
 	}
 
-	return nil
+	return rows, nil
 }
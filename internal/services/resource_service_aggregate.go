@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type GroupCount struct {
+	Value any
+	Count int
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) Count(where whereT) (int, error) {
+	return s.CountContext(context.Background(), where)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CountContext(ctx context.Context, where whereT) (int, error) {
+	var count int
+	err := s.queryAggregate(ctx, where, "COUNT(*)", &count)
+
+	return count, err
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) Exists(where whereT) (bool, error) {
+	return s.ExistsContext(context.Background(), where)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) ExistsContext(ctx context.Context, where whereT) (bool, error) {
+	whereString, params, err := s.whereClause(where)
+
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+s.tableName+whereString+")", params...).Scan(&exists)
+
+	return exists, translateError(err)
+}
+
+// Sum returns the sum of column over the matching rows, zero when no rows match. T is the
+// type the sum is scanned into, so int64 or string keeps integer and decimal sums exact:
+//
+//	total, err := services.Sum[int64](&eventService.ResourceService, where, "ownerUserId")
+func Sum[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (T, error) {
+	return SumContext[T](context.Background(), s, where, column)
+}
+
+func SumContext[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](ctx context.Context, s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (T, error) {
+	var sum T
+
+	if !slices.Contains(s.columns, column) {
+		return sum, validationError("cannot aggregate unknown column " + column)
+	}
+
+	err := s.queryAggregate(ctx, where, "COALESCE(SUM("+column+"), 0)", &sum)
+
+	return sum, err
+}
+
+// Avg returns the average of column over the matching rows, nil when no rows match
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) Avg(where whereT, column string) (*float64, error) {
+	return s.AvgContext(context.Background(), where, column)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) AvgContext(ctx context.Context, where whereT, column string) (*float64, error) {
+	if !slices.Contains(s.columns, column) {
+		return nil, validationError("cannot aggregate unknown column " + column)
+	}
+
+	var avg sql.NullFloat64
+	err := s.queryAggregate(ctx, where, "AVG("+column+")", &avg)
+
+	if err != nil || !avg.Valid {
+		return nil, err
+	}
+
+	return &avg.Float64, nil
+}
+
+// Min returns the smallest value of column over the matching rows, nil when no rows match.
+// Go methods cannot take type parameters, so the service is passed in:
+//
+//	first, err := services.Min[time.Time](&userService.ResourceService, where, "createdAt")
+func Min[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (*T, error) {
+	return MinContext[T](context.Background(), s, where, column)
+}
+
+func MinContext[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](ctx context.Context, s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (*T, error) {
+	return queryExtreme[T](ctx, s, where, "MIN", column)
+}
+
+// Max returns the largest value of column over the matching rows, nil when no rows match
+func Max[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (*T, error) {
+	return MaxContext[T](context.Background(), s, where, column)
+}
+
+func MaxContext[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](ctx context.Context, s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, column string) (*T, error) {
+	return queryExtreme[T](ctx, s, where, "MAX", column)
+}
+
+func queryExtreme[T any, modelT any, createT Creater, updateT Updater, whereT Wherer, includeT any](ctx context.Context, s *ResourceService[modelT, createT, updateT, whereT, includeT], where whereT, function string, column string) (*T, error) {
+	if !slices.Contains(s.columns, column) {
+		return nil, validationError("cannot aggregate unknown column " + column)
+	}
+
+	// scanning into a pointer leaves it nil for the NULL returned when no rows match
+	var value *T
+	err := s.queryAggregate(ctx, where, function+"("+column+")", util.ValueScanTarget(&value))
+
+	return value, err
+}
+
+// GroupBy counts the matching rows for each distinct value of column
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GroupBy(where whereT, column string) ([]GroupCount, error) {
+	return s.GroupByContext(context.Background(), where, column)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) GroupByContext(ctx context.Context, where whereT, column string) ([]GroupCount, error) {
+	if !slices.Contains(s.columns, column) {
+		return nil, validationError("cannot group by unknown column " + column)
+	}
+
+	whereString, params, err := s.whereClause(where)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT " + column + ", COUNT(*) FROM " + s.tableName + whereString + " GROUP BY " + column + " ORDER BY " + column
+
+	rows, err := s.db.QueryContext(ctx, sql, params...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	defer rows.Close()
+
+	groups := []GroupCount{}
+	for rows.Next() {
+		var group GroupCount
+		if err := rows.Scan(&group.Value, &group.Count); err != nil {
+			return nil, err
+		}
+
+		// text columns are returned by the driver as raw bytes
+		if value, ok := group.Value.([]byte); ok {
+			group.Value = string(value)
+		}
+
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) queryAggregate(ctx context.Context, where whereT, expression string, dest any) error {
+	whereString, params, err := s.whereClause(where)
+
+	if err != nil {
+		return err
+	}
+
+	return translateError(s.db.QueryRowContext(ctx, "SELECT "+expression+" FROM "+s.tableName+whereString, params...).Scan(dest))
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) whereClause(where whereT) (string, []any, error) {
+	if s.status == ServiceStatusFailed {
+		return "", nil, ErrServiceFailed
+	}
+
+	whereString, params, err := where.SQL()
+
+	if err != nil {
+		return "", nil, invalidInput(err)
+	}
+
+	if whereString != "" {
+		whereString = " WHERE " + whereString
+	}
+
+	return whereString, params, nil
+}
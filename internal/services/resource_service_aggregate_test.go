@@ -0,0 +1,156 @@
+package services
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"smithsolutions/go-api/internal/filters"
+)
+
+// answerValue answers every query with a single row holding value
+func answerValue(value *driver.Value) func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"value"}, [][]driver.Value{{*value}}, nil
+	}
+}
+
+func TestSum(t *testing.T) {
+	service, stub := newTestService(t)
+	var value driver.Value
+	stub.Query = answerValue(&value)
+
+	// mysql returns integer sums as DECIMAL, which float64 cannot hold exactly past 2^53
+	value = []byte("9007199254740993")
+	total, err := Sum[int64](service, testWhere{Name: filters.StrEquals("a")}, "id")
+
+	if err != nil || total != 9007199254740993 {
+		t.Errorf("Sum[int64] = %v, %v", total, err)
+	}
+
+	value = []byte("12345678901234567.89")
+	exact, err := Sum[string](service, testWhere{}, "id")
+
+	if err != nil || exact != "12345678901234567.89" {
+		t.Errorf("Sum[string] = %v, %v", exact, err)
+	}
+
+	want := []string{
+		"SELECT COALESCE(SUM(id), 0) FROM things WHERE name = ?",
+		"SELECT COALESCE(SUM(id), 0) FROM things",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+
+	if _, err := Sum[int64](service, testWhere{}, "password"); err == nil || err.Error() != "cannot aggregate unknown column password" {
+		t.Errorf("expected the unknown column to be rejected, got %v", err)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	service, stub := newTestService(t)
+	var value driver.Value
+	stub.Query = answerValue(&value)
+
+	value = int64(42)
+	largest, err := Max[int](service, testWhere{}, "id")
+
+	if err != nil || largest == nil || *largest != 42 {
+		t.Errorf("Max id = %v, %v", largest, err)
+	}
+
+	value = []byte("a")
+	first, err := Min[string](service, testWhere{}, "name")
+
+	if err != nil || first == nil || *first != "a" {
+		t.Errorf("Min name = %v, %v", first, err)
+	}
+
+	value = nil
+	none, err := Min[int](service, testWhere{}, "id")
+
+	if err != nil || none != nil {
+		t.Errorf("Min over no rows = %v, %v", none, err)
+	}
+
+	want := []string{
+		"SELECT MAX(id) FROM things",
+		"SELECT MIN(name) FROM things",
+		"SELECT MIN(id) FROM things",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+
+	if _, err := Max[int](service, testWhere{}, "password"); err == nil || err.Error() != "cannot aggregate unknown column password" {
+		t.Errorf("expected the unknown column to be rejected, got %v", err)
+	}
+}
+
+func TestCountExistsAvg(t *testing.T) {
+	service, stub := newTestService(t)
+	var value driver.Value
+	stub.Query = answerValue(&value)
+	where := testWhere{Name: filters.StrEquals("a")}
+
+	value = int64(3)
+	if count, err := service.Count(where); err != nil || count != 3 {
+		t.Errorf("Count = %v, %v", count, err)
+	}
+
+	value = int64(1)
+	if exists, err := service.Exists(where); err != nil || !exists {
+		t.Errorf("Exists = %v, %v", exists, err)
+	}
+
+	value = nil
+	if avg, err := service.Avg(where, "id"); err != nil || avg != nil {
+		t.Errorf("Avg over no rows = %v, %v", avg, err)
+	}
+
+	want := []string{
+		"SELECT COUNT(*) FROM things WHERE name = ?",
+		"SELECT EXISTS(SELECT 1 FROM things WHERE name = ?)",
+		"SELECT AVG(id) FROM things WHERE name = ?",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	service, stub := newTestService(t)
+	stub.Query = func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"name", "COUNT(*)"}, [][]driver.Value{{[]byte("a"), int64(2)}, {[]byte("b"), int64(1)}}, nil
+	}
+
+	groups, err := service.GroupBy(testWhere{}, "name")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []GroupCount{{Value: "a", Count: 2}, {Value: "b", Count: 1}}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups = %+v, want %+v", groups, want)
+	}
+
+	if want := []string{"SELECT name, COUNT(*) FROM things GROUP BY name ORDER BY name"}; !slices.Equal(stub.Log(), want) {
+		t.Errorf("statements = %q, want %q", stub.Log(), want)
+	}
+}
+
+func TestMinTime(t *testing.T) {
+	_, eventService, stub := newRelatedServices(t)
+	stub.Query = func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"value"}, [][]driver.Value{{[]byte("2024-05-01 12:30:00")}}, nil
+	}
+
+	first, err := Min[time.Time](&eventService.ResourceService, WhereEvent{}, "createdAt")
+
+	if err != nil || first == nil || !first.Equal(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("Min createdAt = %v, %v", first, err)
+	}
+}
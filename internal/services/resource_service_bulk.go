@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// mysql rejects prepared statements with more than 65535 placeholders
+const maxPlaceholders = 65535
+
+var ErrUnfilteredMutation error = &Error{Kind: ErrValidation, Message: "refusing to update or delete every row without a filter, use UpdateAll or DeleteAll instead"}
+
+// CreateMany inserts rows using multi row insert statements. Rows that share the same set of
+// columns are batched together and split into chunks that stay under the placeholder limit.
+// Chunks are not atomic on their own, run CreateMany inside a UnitOfWork when that matters.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CreateMany(data []createT) (int, error) {
+	return s.CreateManyContext(context.Background(), data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) CreateManyContext(ctx context.Context, data []createT) (int, error) {
+	if s.status == ServiceStatusFailed {
+		return 0, ErrServiceFailed
+	}
+
+	totalRowsAffected := 0
+
+	var batchColumns []string
+	batchParams := [][]any{}
+
+	flush := func() error {
+		if len(batchParams) == 0 {
+			return nil
+		}
+
+		rowsAffected, err := s.insertRows(ctx, batchColumns, batchParams)
+		totalRowsAffected += rowsAffected
+		batchParams = [][]any{}
+
+		return err
+	}
+
+	// validate and build every row first so an invalid row does not leave earlier batches inserted
+	rowColumns := [][]string{}
+	rowParams := [][]any{}
+
+	for _, row := range data {
+		if err := validateInput(row); err != nil {
+			return 0, err
+		}
+
+		columns, params, err := row.SQL()
+
+		if err != nil {
+			return 0, invalidInput(err)
+		}
+
+		if len(params) <= 0 {
+			return 0, validationError("no values provided for insert statement")
+		}
+
+		rowColumns = append(rowColumns, columns)
+		rowParams = append(rowParams, params)
+	}
+
+	for i, params := range rowParams {
+		if !slices.Equal(rowColumns[i], batchColumns) {
+			if err := flush(); err != nil {
+				return totalRowsAffected, err
+			}
+			batchColumns = rowColumns[i]
+		}
+
+		batchParams = append(batchParams, params)
+	}
+
+	if err := flush(); err != nil {
+		return totalRowsAffected, err
+	}
+
+	return totalRowsAffected, nil
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) insertRows(ctx context.Context, columns []string, rows [][]any) (int, error) {
+	rowPlaceholders := strings.Repeat("?, ", len(columns))
+	rowPlaceholders = "(" + rowPlaceholders[:len(rowPlaceholders)-2] + ")"
+
+	chunkSize := maxPlaceholders / len(columns)
+	totalRowsAffected := 0
+
+	for start := 0; start < len(rows); start += chunkSize {
+		chunk := rows[start:min(start+chunkSize, len(rows))]
+
+		placeholders := []string{}
+		params := []any{}
+		for _, row := range chunk {
+			placeholders = append(placeholders, rowPlaceholders)
+			params = append(params, row...)
+		}
+
+		sql := "INSERT INTO " + s.tableName + " (" + strings.Join(columns, ",") + ") VALUES " + strings.Join(placeholders, ", ")
+		result, err := s.db.ExecContext(ctx, sql, params...)
+
+		if err != nil {
+			return totalRowsAffected, s.translateWriteError(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		totalRowsAffected += int(rowsAffected)
+
+		if err != nil {
+			return totalRowsAffected, err
+		}
+	}
+
+	return totalRowsAffected, nil
+}
+
+// UpdateMany updates every row matching where. An empty filter returns ErrUnfilteredMutation.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateMany(where whereT, data updateT) (int, error) {
+	return s.UpdateManyContext(context.Background(), where, data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateManyContext(ctx context.Context, where whereT, data updateT) (int, error) {
+	whereString, whereParams, err := where.SQL()
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	if whereString == "" {
+		return 0, ErrUnfilteredMutation
+	}
+
+	return s.update(ctx, whereString, whereParams, data)
+}
+
+// UpdateAll updates every row in the table
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateAll(data updateT) (int, error) {
+	return s.UpdateAllContext(context.Background(), data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpdateAllContext(ctx context.Context, data updateT) (int, error) {
+	return s.update(ctx, "", []any{}, data)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) update(ctx context.Context, whereString string, whereParams []any, data updateT) (int, error) {
+	if s.status == ServiceStatusFailed {
+		return 0, ErrServiceFailed
+	}
+
+	if err := validateInput(data); err != nil {
+		return 0, err
+	}
+
+	setString, params, err := data.SQL()
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	if len(params) <= 0 {
+		return 0, validationError("no values provided for update statement")
+	}
+
+	sql := "UPDATE " + s.tableName + " SET " + setString
+
+	if whereString != "" {
+		sql += " WHERE " + whereString
+		params = append(params, whereParams...)
+	}
+
+	result, err := s.db.ExecContext(ctx, sql, params...)
+
+	if err != nil {
+		return 0, s.translateWriteError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	return int(rowsAffected), err
+}
+
+// DeleteMany deletes every row matching where. An empty filter returns ErrUnfilteredMutation.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteMany(where whereT) (int, error) {
+	return s.DeleteManyContext(context.Background(), where)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteManyContext(ctx context.Context, where whereT) (int, error) {
+	whereString, whereParams, err := where.SQL()
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	if whereString == "" {
+		return 0, ErrUnfilteredMutation
+	}
+
+	return s.delete(ctx, whereString, whereParams)
+}
+
+// DeleteAll deletes every row in the table
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteAll() (int, error) {
+	return s.DeleteAllContext(context.Background())
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) DeleteAllContext(ctx context.Context) (int, error) {
+	return s.delete(ctx, "", []any{})
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) delete(ctx context.Context, whereString string, params []any) (int, error) {
+	if s.status == ServiceStatusFailed {
+		return 0, ErrServiceFailed
+	}
+
+	sql := "DELETE FROM " + s.tableName
+
+	if whereString != "" {
+		sql += " WHERE " + whereString
+	}
+
+	result, err := s.db.ExecContext(ctx, sql, params...)
+
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+
+	return int(rowsAffected), err
+}
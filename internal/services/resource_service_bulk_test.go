@@ -0,0 +1,133 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestCreateManySplitsChunks(t *testing.T) {
+	service, stub := newTestService(t)
+	stub.Exec = func(query string, args []driver.Value) (driver.Result, error) {
+		return driver.RowsAffected(len(args)), nil
+	}
+
+	// a single column fills a chunk with maxPlaceholders rows
+	rows := make([]testCreate, maxPlaceholders+1)
+	for i := range rows {
+		rows[i] = testCreate{Name: "thing"}
+	}
+
+	rowsAffected, err := service.CreateMany(rows)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rowsAffected != len(rows) {
+		t.Errorf("rows affected = %d, want %d", rowsAffected, len(rows))
+	}
+
+	statements := stub.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(statements))
+	}
+
+	if len(statements[0].Args) != maxPlaceholders || len(statements[1].Args) != 1 {
+		t.Errorf("chunks hold %d and %d rows, want %d and 1", len(statements[0].Args), len(statements[1].Args), maxPlaceholders)
+	}
+
+	if want := "INSERT INTO things (name) VALUES (?)"; statements[1].Query != want {
+		t.Errorf("last chunk = %q, want %q", statements[1].Query, want)
+	}
+
+	if !strings.HasPrefix(statements[0].Query, "INSERT INTO things (name) VALUES (?), (?), ") {
+		t.Errorf("first chunk = %.60q...", statements[0].Query)
+	}
+}
+
+func TestCreateManyAbortsBeforeInserting(t *testing.T) {
+	service, stub := newTestService(t)
+
+	_, err := service.CreateMany([]testCreate{{Name: "a"}, {Name: "b"}, {}})
+
+	if err == nil || err.Error() != "name is required" {
+		t.Errorf("expected the invalid row to be rejected, got %v", err)
+	}
+
+	if log := stub.Log(); len(log) != 0 {
+		t.Errorf("expected no statements, got %q", log)
+	}
+}
+
+func TestCreateManyValidatesEveryRowFirst(t *testing.T) {
+	service, stub := newTestService(t)
+
+	rows := make([]testCreate, maxPlaceholders+1)
+	for i := range rows {
+		rows[i] = testCreate{Name: "a"}
+	}
+	// the row that fails validation comes after a full batch
+	rows[maxPlaceholders].Name = "far too long"
+
+	_, err := service.CreateMany(rows)
+
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) || !errors.Is(err, ErrValidation) || serviceErr.Message != "name must be at most 10 characters" {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+
+	if log := stub.Log(); len(log) != 0 {
+		t.Errorf("expected no statements, got %d", len(log))
+	}
+}
+
+func TestMutationsRequireFilter(t *testing.T) {
+	service, stub := newTestService(t)
+
+	if _, err := service.UpdateMany(testWhere{}, testUpdate{Name: util.ToPointer("b")}); !errors.Is(err, ErrUnfilteredMutation) {
+		t.Errorf("UpdateMany without a filter = %v, want ErrUnfilteredMutation", err)
+	}
+
+	if _, err := service.DeleteMany(testWhere{}); !errors.Is(err, ErrUnfilteredMutation) {
+		t.Errorf("DeleteMany without a filter = %v, want ErrUnfilteredMutation", err)
+	}
+
+	if log := stub.Log(); len(log) != 0 {
+		t.Errorf("expected no statements, got %q", log)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	service, stub := newTestService(t)
+	where := testWhere{Name: filters.StrEquals("a")}
+	update := testUpdate{Name: util.ToPointer("b")}
+
+	calls := []func() (int, error){
+		func() (int, error) { return service.UpdateMany(where, update) },
+		func() (int, error) { return service.UpdateAll(update) },
+		func() (int, error) { return service.DeleteMany(where) },
+		func() (int, error) { return service.DeleteAll() },
+	}
+
+	for _, call := range calls {
+		if rowsAffected, err := call(); err != nil || rowsAffected != 1 {
+			t.Errorf("got %d, %v", rowsAffected, err)
+		}
+	}
+
+	want := []string{
+		"UPDATE things SET name=? WHERE name = ?",
+		"UPDATE things SET name=?",
+		"DELETE FROM things WHERE name = ?",
+		"DELETE FROM things",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+// testRow and its input types back a service over a "things" table, so the generic service
+// behaviour is tested apart from the user and event models
+type testRow struct {
+	Id   int
+	Name string
+}
+
+type testCreate struct {
+	Name string `validate:"max=10"`
+}
+
+func (c testCreate) SQL() ([]string, []any, error) {
+	if c.Name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+	return util.GetCreateSQL(c)
+}
+
+type testUpdate struct {
+	Name *string
+}
+
+func (u testUpdate) SQL() (string, []any, error) {
+	return util.GetUpdateSQL(u)
+}
+
+type testWhere struct {
+	Name *filters.StringFilter
+}
+
+func (w testWhere) SQL() (string, []any, error) {
+	return util.GetWhereSQL(w)
+}
+
+type testInclude struct{}
+
+type testService = ResourceService[testRow, testCreate, testUpdate, testWhere, testInclude]
+
+func newTestService(t *testing.T) (*testService, *stubdb.DB) {
+	db, stub := stubdb.Open(t)
+	service := SetupResourceService[testRow, testCreate, testUpdate, testWhere, testInclude](db, "things", &testRow{})
+
+	return &service, stub
+}
+
+// answerRows answers SELECT COUNT(*) with the number of rows and every other query with rows
+func answerRows(rows ...[]driver.Value) func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT COUNT(*)") {
+			return []string{"COUNT(*)"}, [][]driver.Value{{int64(len(rows))}}, nil
+		}
+		return []string{"id", "name"}, rows, nil
+	}
+}
+
+func TestGetManyWithOptions(t *testing.T) {
+	service, stub := newTestService(t)
+	stub.Query = answerRows([]driver.Value{int64(1), "a"}, []driver.Value{int64(3), "a"})
+
+	rows, pageInfo, err := service.GetManyWithOptions(testWhere{Name: filters.StrEquals("a")}, nil, QueryOptions{
+		OrderBy:   []OrderBy{{Column: "name", Direction: SortDescending}, {Column: "id"}},
+		Limit:     util.ToPointer(10),
+		Offset:    util.ToPointer(20),
+		WithTotal: true,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"SELECT COUNT(*) FROM things WHERE name = ?",
+		"SELECT id, name FROM things WHERE name = ? ORDER BY name DESC, id ASC LIMIT 10 OFFSET 20",
+	}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+
+	if wantRows := []testRow{{Id: 1, Name: "a"}, {Id: 3, Name: "a"}}; !reflect.DeepEqual(*rows, wantRows) {
+		t.Errorf("rows = %+v, want %+v", *rows, wantRows)
+	}
+
+	if pageInfo.Total == nil || *pageInfo.Total != 2 || *pageInfo.Limit != 10 || *pageInfo.Offset != 20 {
+		t.Errorf("page info = %+v", pageInfo)
+	}
+}
+
+func TestGetManyWithOptionsErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		options QueryOptions
+		err     string
+	}{
+		{"unknown order by column", QueryOptions{OrderBy: []OrderBy{{Column: "password"}}}, "cannot order by unknown column password"},
+		{"invalid direction", QueryOptions{OrderBy: []OrderBy{{Column: "name", Direction: "UP"}}}, "invalid sort direction UP"},
+		{"negative limit", QueryOptions{Limit: util.ToPointer(-1)}, "limit cannot be negative"},
+		{"negative offset", QueryOptions{Limit: util.ToPointer(1), Offset: util.ToPointer(-1)}, "offset cannot be negative"},
+		{"offset without limit", QueryOptions{Offset: util.ToPointer(1)}, "offset requires a limit"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, stub := newTestService(t)
+
+			_, _, err := service.GetManyWithOptions(testWhere{}, nil, c.options)
+
+			if err == nil || err.Error() != c.err {
+				t.Errorf("got error %v, want %q", err, c.err)
+			}
+
+			if log := stub.Log(); len(log) != 0 {
+				t.Errorf("expected no statements, got %q", log)
+			}
+		})
+	}
+}
+
+func TestUpdateOneClearsNullableColumns(t *testing.T) {
+	db, stub := stubdb.Open(t)
+	eventService := NewEventService(db, nil)
+
+	_, err := eventService.UpdateOne(4, UpdateEvent{Label: util.ToPointer("a")})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := stub.Statements()
+	if want := "UPDATE events SET label=?, coverPhotoPath=?, metadata=? WHERE id=? LIMIT 1"; len(statements) != 1 || statements[0].Query != want {
+		t.Fatalf("statements = %q, want %q", stub.Log(), want)
+	}
+
+	// the unset cover photo and metadata are written as NULL
+	if want := []driver.Value{"a", nil, nil, int64(4)}; !slices.Equal(statements[0].Args, want) {
+		t.Errorf("args = %v, want %v", statements[0].Args, want)
+	}
+}
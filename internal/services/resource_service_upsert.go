@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+// Upsert inserts create, or applies update to the existing row when the insert conflicts on
+// conflictColumns. The id of the inserted or updated row is returned.
+// MySQL cannot name the conflict target and resolves conflicts on any unique key, so there
+// conflictColumns must be the columns of the table's only unique key besides the primary key.
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) Upsert(create createT, update updateT, conflictColumns []string) (int, error) {
+	return s.UpsertContext(context.Background(), create, update, conflictColumns)
+}
+
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) UpsertContext(ctx context.Context, create createT, update updateT, conflictColumns []string) (int, error) {
+	if s.status == ServiceStatusFailed {
+		return 0, ErrServiceFailed
+	}
+
+	if len(conflictColumns) <= 0 {
+		return 0, validationError("no conflict columns provided for upsert statement")
+	}
+
+	for _, column := range conflictColumns {
+		if !slices.Contains(s.columns, column) {
+			return 0, validationError("cannot upsert on unknown column " + column)
+		}
+	}
+
+	if err := validateInput(create); err != nil {
+		return 0, err
+	}
+
+	if err := validateInput(update); err != nil {
+		return 0, err
+	}
+
+	columns, params, err := create.SQL()
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	if len(params) <= 0 {
+		return 0, validationError("no values provided for insert statement")
+	}
+
+	setString, setParams, err := update.SQL()
+
+	if err != nil {
+		return 0, invalidInput(err)
+	}
+
+	paramPlaceholders := strings.Repeat("?, ", len(params))
+	paramPlaceholders = paramPlaceholders[:len(paramPlaceholders)-2]
+
+	sql := "INSERT INTO " + s.tableName + " (" + strings.Join(columns, ",") + ") VALUES (" + paramPlaceholders + ")"
+	params = append(params, setParams...)
+
+	switch util.GetDialect() {
+	case util.DialectMySQL:
+		err := s.checkUniqueKey(ctx, conflictColumns)
+
+		if err != nil {
+			return 0, err
+		}
+
+		// mysql resolves the conflict against any unique key, LAST_INSERT_ID(id) makes the
+		// updated row's id available through LastInsertId
+		assignments := "id=LAST_INSERT_ID(id)"
+		if setString != "" {
+			assignments = setString + ", " + assignments
+		}
+
+		result, err := s.db.ExecContext(ctx, sql+" ON DUPLICATE KEY UPDATE "+assignments, params...)
+
+		if err != nil {
+			return 0, s.translateWriteError(err)
+		}
+
+		id, err := result.LastInsertId()
+
+		return int(id), err
+	case util.DialectSQLite:
+		assignments := "id=id"
+		if setString != "" {
+			assignments = setString
+		}
+
+		sql += " ON CONFLICT (" + strings.Join(conflictColumns, ", ") + ") DO UPDATE SET " + assignments + " RETURNING id"
+
+		var id int
+		err := s.db.QueryRowContext(ctx, sql, params...).Scan(&id)
+
+		return id, s.translateWriteError(err)
+	}
+
+	return 0, errors.New("upsert is not supported for the " + util.GetDialect().String() + " dialect")
+}
+
+// checkUniqueKey makes sure the table has a single unique key besides the primary key and
+// that it is made up of columns, since that is the key mysql upserts resolve conflicts on
+func (s *ResourceService[modelT, createT, updateT, whereT, includeT]) checkUniqueKey(ctx context.Context, columns []string) error {
+	sql := "SELECT INDEX_NAME, COLUMN_NAME FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND NON_UNIQUE = 0 AND INDEX_NAME <> 'PRIMARY'"
+	rows, err := s.db.QueryContext(ctx, sql, s.tableName)
+
+	if err != nil {
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	keys := map[string][]string{}
+	for rows.Next() {
+		var key, column string
+		if err := rows.Scan(&key, &column); err != nil {
+			return err
+		}
+		keys[key] = append(keys[key], strings.ToLower(column))
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(keys) != 1 {
+		return errors.New("mysql upserts need " + s.tableName + " to have exactly one unique key besides the primary key")
+	}
+
+	for _, keyColumns := range keys {
+		if len(keyColumns) != len(columns) {
+			return validationError("conflict columns do not match the unique key of " + s.tableName)
+		}
+		for _, column := range columns {
+			// mysql column names are case insensitive
+			if !slices.Contains(keyColumns, strings.ToLower(column)) {
+				return validationError("conflict columns do not match the unique key of " + s.tableName)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"database/sql/driver"
+	"slices"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+// upsertResult is what mysql reports for an upsert, one row affected for an insert and two
+// for an update of the existing row
+type upsertResult struct {
+	id           int64
+	rowsAffected int64
+}
+
+func (r upsertResult) LastInsertId() (int64, error) {
+	return r.id, nil
+}
+
+func (r upsertResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// answerUniqueKeys answers the information_schema lookup of the table's unique keys
+func answerUniqueKeys(keys ...[]driver.Value) func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+	return func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"INDEX_NAME", "COLUMN_NAME"}, keys, nil
+	}
+}
+
+func TestUpsertMySQL(t *testing.T) {
+	cases := []struct {
+		name   string
+		result upsertResult
+	}{
+		{"insert", upsertResult{id: 4, rowsAffected: 1}},
+		{"update", upsertResult{id: 2, rowsAffected: 2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, stub := newTestService(t)
+			stub.Query = answerUniqueKeys([]driver.Value{"things_name", "name"})
+			stub.Exec = func(query string, args []driver.Value) (driver.Result, error) {
+				return c.result, nil
+			}
+
+			id, err := service.Upsert(testCreate{Name: "a"}, testUpdate{Name: util.ToPointer("b")}, []string{"name"})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// the id comes from LAST_INSERT_ID(id) for updates as well as inserts
+			if id != int(c.result.id) {
+				t.Errorf("id = %d, want %d", id, c.result.id)
+			}
+
+			statements := stub.Statements()
+			if len(statements) != 2 {
+				t.Fatalf("expected 2 statements, got %q", stub.Log())
+			}
+
+			if !strings.Contains(statements[0].Query, "information_schema.STATISTICS") || !slices.Equal(statements[0].Args, []driver.Value{"things"}) {
+				t.Errorf("unique key lookup = %q %v", statements[0].Query, statements[0].Args)
+			}
+
+			if want := "INSERT INTO things (name) VALUES (?) ON DUPLICATE KEY UPDATE name=?, id=LAST_INSERT_ID(id)"; statements[1].Query != want {
+				t.Errorf("upsert = %q, want %q", statements[1].Query, want)
+			}
+
+			if want := []driver.Value{"a", "b"}; !slices.Equal(statements[1].Args, want) {
+				t.Errorf("args = %v, want %v", statements[1].Args, want)
+			}
+		})
+	}
+}
+
+type emptyUpdate struct{}
+
+func (u emptyUpdate) SQL() (string, []any, error) {
+	return util.GetUpdateSQL(u)
+}
+
+func TestUpsertEmptyUpdate(t *testing.T) {
+	db, stub := stubdb.Open(t)
+	service := SetupResourceService[testRow, testCreate, emptyUpdate, testWhere, testInclude](db, "things", &testRow{})
+	stub.Query = answerUniqueKeys([]driver.Value{"things_name", "name"})
+	stub.Exec = func(query string, args []driver.Value) (driver.Result, error) {
+		return upsertResult{id: 2, rowsAffected: 1}, nil
+	}
+
+	id, err := service.Upsert(testCreate{Name: "a"}, emptyUpdate{}, []string{"name"})
+
+	if err != nil || id != 2 {
+		t.Fatalf("got %d, %v", id, err)
+	}
+
+	// the existing row is left as it is but its id is still returned
+	if want := "INSERT INTO things (name) VALUES (?) ON DUPLICATE KEY UPDATE id=LAST_INSERT_ID(id)"; stub.Log()[1] != want {
+		t.Errorf("upsert = %q, want %q", stub.Log()[1], want)
+	}
+}
+
+func TestUpsertSQLite(t *testing.T) {
+	util.SetDialect(util.DialectSQLite)
+	t.Cleanup(func() { util.SetDialect(util.DialectMySQL) })
+
+	service, stub := newTestService(t)
+	stub.Query = func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(3)}}, nil
+	}
+
+	id, err := service.Upsert(testCreate{Name: "a"}, testUpdate{Name: util.ToPointer("b")}, []string{"name"})
+
+	if err != nil || id != 3 {
+		t.Fatalf("got %d, %v", id, err)
+	}
+
+	want := []string{"INSERT INTO things (name) VALUES (?) ON CONFLICT (name) DO UPDATE SET name=? RETURNING id"}
+	if log := stub.Log(); !slices.Equal(log, want) {
+		t.Errorf("statements = %q, want %q", log, want)
+	}
+}
+
+func TestUpsertErrors(t *testing.T) {
+	cases := []struct {
+		name            string
+		conflictColumns []string
+		keys            [][]driver.Value
+		err             string
+	}{
+		{"no conflict columns", nil, nil, "no conflict columns provided for upsert statement"},
+		{"unknown column", []string{"password"}, nil, "cannot upsert on unknown column password"},
+		{"no unique key", []string{"name"}, nil, "mysql upserts need things to have exactly one unique key besides the primary key"},
+		{"several unique keys", []string{"name"}, [][]driver.Value{{"things_name", "name"}, {"things_slug", "slug"}}, "mysql upserts need things to have exactly one unique key besides the primary key"},
+		{"other columns", []string{"id"}, [][]driver.Value{{"things_name", "name"}}, "conflict columns do not match the unique key of things"},
+		{"part of the key", []string{"name"}, [][]driver.Value{{"things_name_id", "name"}, {"things_name_id", "id"}}, "conflict columns do not match the unique key of things"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, stub := newTestService(t)
+			stub.Query = answerUniqueKeys(c.keys...)
+
+			_, err := service.Upsert(testCreate{Name: "a"}, testUpdate{}, c.conflictColumns)
+
+			if err == nil || err.Error() != c.err {
+				t.Errorf("got error %v, want %q", err, c.err)
+			}
+
+			for _, query := range stub.Log() {
+				if strings.HasPrefix(query, "INSERT") {
+					t.Errorf("expected no insert, got %q", query)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// UnitOfWork runs callbacks against a set of services bound to a single transaction.
+// The build function receives the querier the services should use, which is either
+// the root database or the active transaction.
+type UnitOfWork[servicesT any] struct {
+	Services servicesT
+
+	querier util.Querier
+	build   func(db util.Querier) servicesT
+	depth   int
+}
+
+func NewUnitOfWork[servicesT any](db util.Querier, build func(db util.Querier) servicesT) *UnitOfWork[servicesT] {
+	return &UnitOfWork[servicesT]{
+		Services: build(db),
+		querier:  db,
+		build:    build,
+	}
+}
+
+// Run calls fn with transaction bound copies of the services. The transaction is committed
+// when fn returns nil and rolled back when it returns an error or panics. Calling Run on the
+// unit of work passed to fn creates a savepoint that is rolled back independently.
+func (u *UnitOfWork[servicesT]) Run(ctx context.Context, fn func(tx *UnitOfWork[servicesT]) error) (err error) {
+	if tx, ok := u.querier.(*sql.Tx); ok {
+		return u.runSavepoint(ctx, tx, fn)
+	}
+
+	beginner, ok := u.querier.(txBeginner)
+	if !ok {
+		return errors.New("querier does not support transactions")
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	child := &UnitOfWork[servicesT]{
+		Services: u.build(tx),
+		querier:  tx,
+		build:    u.build,
+		depth:    u.depth + 1,
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return fn(child)
+}
+
+func (u *UnitOfWork[servicesT]) runSavepoint(ctx context.Context, tx *sql.Tx, fn func(tx *UnitOfWork[servicesT]) error) (err error) {
+	savepoint := fmt.Sprintf("sp_%d", u.depth)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	child := &UnitOfWork[servicesT]{
+		Services: u.Services,
+		querier:  tx,
+		build:    u.build,
+		depth:    u.depth + 1,
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(recovered)
+		}
+
+		if err != nil {
+			if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return
+		}
+
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	}()
+
+	return fn(child)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"smithsolutions/go-api/internal/stubdb"
+	"smithsolutions/go-api/internal/util"
+)
+
+type testServices struct {
+	Users *UserService
+}
+
+func newTestUnitOfWork(t *testing.T) (*UnitOfWork[testServices], *stubdb.DB) {
+	db, stub := stubdb.Open(t)
+
+	return NewUnitOfWork(db, func(db util.Querier) testServices {
+		return testServices{Users: NewUserService(db, nil)}
+	}), stub
+}
+
+const deleteUser = "DELETE FROM users WHERE id =? LIMIT 1"
+
+func deleteTestUser(tx *UnitOfWork[testServices]) error {
+	_, err := tx.Services.Users.DeleteOneById(1)
+	return err
+}
+
+func TestUnitOfWorkCommits(t *testing.T) {
+	unitOfWork, stub := newTestUnitOfWork(t)
+
+	err := unitOfWork.Run(context.Background(), deleteTestUser)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"BEGIN", deleteUser, "COMMIT"}; !slices.Equal(stub.Log(), want) {
+		t.Errorf("statements = %q, want %q", stub.Log(), want)
+	}
+}
+
+func TestUnitOfWorkRollsBackOnError(t *testing.T) {
+	unitOfWork, stub := newTestUnitOfWork(t)
+	failed := errors.New("failed")
+
+	err := unitOfWork.Run(context.Background(), func(tx *UnitOfWork[testServices]) error {
+		if err := deleteTestUser(tx); err != nil {
+			return err
+		}
+		return failed
+	})
+
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+
+	if want := []string{"BEGIN", deleteUser, "ROLLBACK"}; !slices.Equal(stub.Log(), want) {
+		t.Errorf("statements = %q, want %q", stub.Log(), want)
+	}
+}
+
+func TestUnitOfWorkRollsBackAndRepanics(t *testing.T) {
+	unitOfWork, stub := newTestUnitOfWork(t)
+
+	defer func() {
+		if recovered := recover(); recovered != "boom" {
+			t.Errorf("expected the panic to be rethrown, got %v", recovered)
+		}
+
+		if want := []string{"BEGIN", deleteUser, "ROLLBACK"}; !slices.Equal(stub.Log(), want) {
+			t.Errorf("statements = %q, want %q", stub.Log(), want)
+		}
+	}()
+
+	unitOfWork.Run(context.Background(), func(tx *UnitOfWork[testServices]) error {
+		deleteTestUser(tx)
+		panic("boom")
+	})
+}
+
+func TestUnitOfWorkSavepoints(t *testing.T) {
+	unitOfWork, stub := newTestUnitOfWork(t)
+	failed := errors.New("failed")
+
+	err := unitOfWork.Run(context.Background(), func(tx *UnitOfWork[testServices]) error {
+		if err := tx.Run(context.Background(), deleteTestUser); err != nil {
+			return err
+		}
+
+		err := tx.Run(context.Background(), func(nested *UnitOfWork[testServices]) error {
+			if err := nested.Run(context.Background(), deleteTestUser); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Errorf("expected the nested callback error, got %v", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT sp_1",
+		deleteUser,
+		"RELEASE SAVEPOINT sp_1",
+		"SAVEPOINT sp_1",
+		"SAVEPOINT sp_2",
+		deleteUser,
+		"RELEASE SAVEPOINT sp_2",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"COMMIT",
+	}
+	if !slices.Equal(stub.Log(), want) {
+		t.Errorf("statements = %q, want %q", stub.Log(), want)
+	}
+}
+
+func TestUnitOfWorkRequiresTransactions(t *testing.T) {
+	unitOfWork := NewUnitOfWork[testServices](nil, func(db util.Querier) testServices {
+		return testServices{}
+	})
+
+	if err := unitOfWork.Run(context.Background(), deleteTestUser); err == nil {
+		t.Error("expected an error for a querier without transactions")
+	}
+}
@@ -1,8 +1,6 @@
 package services
 
 import (
-	"database/sql"
-
 	"smithsolutions/go-api/internal/filters"
 	"smithsolutions/go-api/internal/models"
 	"smithsolutions/go-api/internal/util"
@@ -10,8 +8,9 @@ import (
 
 //go:generate gen CreateUser CreateSQL
 type CreateUser struct {
-	Email        string
-	PasswordHash string
+	Email        string         `validate:"required,email,max=255"`
+	PasswordHash string         `validate:"required,max=255"`
+	Settings     map[string]any `orm:"json"`
 }
 
 // func (c CreateUser) SQL() ([]string, []any, error) {
@@ -19,6 +18,8 @@ type CreateUser struct {
 // }
 
 type UpdateUser struct {
+	PasswordHash *string
+	Settings     *map[string]any `orm:"json"`
 }
 
 func (u UpdateUser) SQL() (string, []any, error) {
@@ -26,15 +27,32 @@ func (u UpdateUser) SQL() (string, []any, error) {
 }
 
 type WhereUser struct {
-	Email *filters.StringFilter
+	Id        *filters.IntFilter
+	Email     *filters.StringFilter
+	Settings  *filters.JSONFilter
+	CreatedAt *filters.TimeFilter
+	UpdatedAt *filters.TimeFilter
+
+	Events          *filters.RelationFilter[WhereEvent]
+	AttendingEvents *filters.RelationFilter[WhereEvent]
+
+	And *[]WhereUser
+	Or  *[]WhereUser
+	Not *WhereUser
 }
 
 func (w WhereUser) SQL() (string, []any, error) {
 	return util.GetWhereSQL(w)
 }
 
+// Match applies the filter to a user in memory, relation filters need the relation loaded
+func (w WhereUser) Match(user *models.User) (bool, error) {
+	return util.MatchWhere(w, user)
+}
+
 type IncludeWithUser struct {
-	Events bool
+	Events          *Include[WhereEvent, IncludeWithEvent]
+	AttendingEvents *Include[WhereEvent, IncludeWithEvent]
 }
 
 type UserService struct {
@@ -44,7 +62,7 @@ type UserService struct {
 	eventService *EventService
 }
 
-func NewUserService(db *sql.DB, eventService *EventService) *UserService {
+func NewUserService(db util.Querier, eventService *EventService) *UserService {
 	serviceBase := SetupResourceService[models.User, CreateUser, UpdateUser, WhereUser, IncludeWithUser](db, "users", &models.User{})
 
 	userService := &UserService{
@@ -52,7 +70,9 @@ func NewUserService(db *sql.DB, eventService *EventService) *UserService {
 		eventService:    eventService,
 	}
 
-	userService.ResourceService.attachRelationsOverrider = userService
+	if eventService != nil {
+		userService.SetEventService(eventService)
+	}
 
 	return userService
 }
@@ -60,18 +80,19 @@ func NewUserService(db *sql.DB, eventService *EventService) *UserService {
 // post initialization dependency injection
 func (s *UserService) SetEventService(eventService *EventService) {
 	s.eventService = eventService
-}
-
-func (s *UserService) AttachRelations(model *models.User, include IncludeWithUser) error {
-	if include.Events {
-		events, err := s.eventService.GetMany(WhereEvent{
-			OwnerUserId: filters.IntEquals(model.Id),
-		}, nil)
-		if err != nil {
-			return err
-		}
-		model.Events = events
-	}
 
-	return nil
+	s.DefineRelation(Relation{
+		Name:       "Events",
+		Kind:       RelationHasMany,
+		Target:     &eventService.ResourceService,
+		ForeignKey: "ownerUserId",
+	})
+	s.DefineRelation(Relation{
+		Name:           "AttendingEvents",
+		Kind:           RelationManyToMany,
+		Target:         &eventService.ResourceService,
+		JoinTable:      "event_attendees",
+		JoinForeignKey: "userId",
+		JoinTargetKey:  "eventId",
+	})
 }
@@ -0,0 +1,188 @@
+// Package stubdb registers a database/sql driver that records statements instead of running
+// them, so services and controllers can be tested without a database.
+package stubdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Statement is a statement run against a DB along with its arguments
+type Statement struct {
+	Query string
+	Args  []driver.Value
+}
+
+// DB records the statements run against it, BEGIN, COMMIT and ROLLBACK included.
+// Query answers SELECT statements, when it is nil every query returns no rows.
+// Exec answers every other statement, when it is nil each one affects a single row.
+type DB struct {
+	Query func(query string, args []driver.Value) ([]string, [][]driver.Value, error)
+	Exec  func(query string, args []driver.Value) (driver.Result, error)
+
+	mu         sync.Mutex
+	statements []Statement
+}
+
+func (db *DB) record(query string, args []driver.Value) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, Statement{Query: query, Args: args})
+}
+
+// Statements returns every statement run so far
+func (db *DB) Statements() []Statement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Statement{}, db.statements...)
+}
+
+// Log returns the query of every statement run so far
+func (db *DB) Log() []string {
+	queries := []string{}
+	for _, statement := range db.Statements() {
+		queries = append(queries, statement.Query)
+	}
+	return queries
+}
+
+var (
+	dbs     sync.Map
+	dbCount atomic.Int64
+)
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+// Open opens a database backed by a new DB that is closed when the test ends
+func Open(t testing.TB) (*sql.DB, *DB) {
+	stub := &DB{}
+	name := strconv.FormatInt(dbCount.Add(1), 10)
+	dbs.Store(name, stub)
+
+	db, err := sql.Open("stub", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a single connection keeps transactions and their statements in order
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		db.Close()
+		dbs.Delete(name)
+	})
+
+	return db, stub
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	stub, _ := dbs.Load(name)
+	return &stubConn{db: stub.(*DB)}, nil
+}
+
+type stubConn struct {
+	db *DB
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{db: c.db, query: query}, nil
+}
+
+func (c *stubConn) Close() error {
+	return nil
+}
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *stubConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN", nil)
+	return &stubTx{db: c.db}, nil
+}
+
+type stubTx struct {
+	db *DB
+}
+
+func (tx *stubTx) Commit() error {
+	tx.db.record("COMMIT", nil)
+	return nil
+}
+
+func (tx *stubTx) Rollback() error {
+	tx.db.record("ROLLBACK", nil)
+	return nil
+}
+
+type stubStmt struct {
+	db    *DB
+	query string
+}
+
+func (s *stubStmt) Close() error {
+	return nil
+}
+
+func (s *stubStmt) NumInput() int {
+	return -1
+}
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(s.query, args)
+
+	if s.db.Exec == nil {
+		return driver.RowsAffected(1), nil
+	}
+
+	return s.db.Exec(s.query, args)
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(s.query, args)
+
+	if s.db.Query == nil {
+		return &stubRows{}, nil
+	}
+
+	columns, values, err := s.db.Query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stubRows{columns: columns, values: values}, nil
+}
+
+type stubRows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *stubRows) Columns() []string {
+	return r.columns
+}
+
+func (r *stubRows) Close() error {
+	return nil
+}
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+
+	copy(dest, r.values[r.next])
+	r.next++
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package util
+
+// Collation is how the database compares string columns, filters evaluated in memory follow it
+// so they return the same rows as the generated SQL
+type Collation int
+
+const (
+	// case insensitive collations such as mysql's default utf8mb4_0900_ai_ci
+	CollationCaseInsensitive Collation = iota
+	// binary or case sensitive collations such as sqlite's default BINARY or mysql's utf8mb4_bin
+	CollationBinary
+)
+
+var currentCollation = CollationCaseInsensitive
+
+// SetCollation sets the collation of the string columns, defaults to case insensitive
+func SetCollation(collation Collation) {
+	currentCollation = collation
+}
+
+func GetCollation() Collation {
+	return currentCollation
+}
+
+func (c Collation) String() string {
+	switch c {
+	case CollationCaseInsensitive:
+		return "case insensitive"
+	case CollationBinary:
+		return "binary"
+	}
+	return "unknown"
+}
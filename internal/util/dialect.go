@@ -0,0 +1,29 @@
+package util
+
+type Dialect int
+
+const (
+	DialectMySQL Dialect = iota
+	DialectSQLite
+)
+
+var currentDialect = DialectMySQL
+
+// SetDialect sets the SQL dialect used by the services and filters, defaults to mysql
+func SetDialect(dialect Dialect) {
+	currentDialect = dialect
+}
+
+func GetDialect() Dialect {
+	return currentDialect
+}
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectMySQL:
+		return "mysql"
+	case DialectSQLite:
+		return "sqlite"
+	}
+	return "unknown"
+}
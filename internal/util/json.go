@@ -0,0 +1,115 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// isJSONField reports if a field is stored as a JSON column, tagged with `orm:"json"`.
+// These fields may be structs, maps or slices and are marshalled on write and
+// unmarshalled on read.
+func isJSONField(fieldT reflect.StructField) bool {
+	return strings.Contains(fieldT.Tag.Get("orm"), "json")
+}
+
+// GetJSONColumnsFromModel returns the columns of a model struct that are stored as JSON
+func GetJSONColumnsFromModel(obj any) []string {
+	rType := reflect.TypeOf(obj)
+	for rType.Kind() == reflect.Pointer {
+		rType = rType.Elem()
+	}
+
+	columns := []string{}
+	for i := 0; i < rType.NumField(); i++ {
+		fieldT := rType.Field(i)
+		if fieldT.IsExported() && isJSONField(fieldT) && !strings.Contains(fieldT.Tag.Get("orm"), "ignore") {
+			columns = append(columns, toLowerCamelCase(fieldT.Name))
+		}
+	}
+
+	return columns
+}
+
+// scanTarget returns the value passed to Scan for a model field
+func scanTarget(field reflect.Value, fieldT reflect.StructField) any {
+	if isJSONField(fieldT) {
+		return jsonScanner{dest: field}
+	}
+
+	if field.Type() == timeType || (field.Kind() == reflect.Pointer && field.Type().Elem() == timeType) {
+		return timeScanner{dest: field}
+	}
+
+	return field.Addr().Interface()
+}
+
+// columnParam returns the statement parameter for a create or update field
+func columnParam(field reflect.Value, fieldT reflect.StructField) (any, error) {
+	if isJSONField(fieldT) {
+		return JSONParam(field.Interface())
+	}
+
+	return field.Interface(), nil
+}
+
+// JSONParam marshals value for a JSON column, nil pointers, maps and slices are stored as NULL
+func JSONParam(value any) (any, error) {
+	rValue := reflect.ValueOf(value)
+
+	if !rValue.IsValid() {
+		return nil, nil
+	}
+
+	for rValue.Kind() == reflect.Pointer || rValue.Kind() == reflect.Interface {
+		if rValue.IsNil() {
+			return nil, nil
+		}
+		rValue = rValue.Elem()
+	}
+
+	switch rValue.Kind() {
+	case reflect.Map, reflect.Slice:
+		if rValue.IsNil() {
+			return nil, nil
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// jsonScanner unmarshals JSON columns into struct, map or slice fields
+type jsonScanner struct {
+	dest reflect.Value
+}
+
+func (j jsonScanner) Scan(src any) error {
+	var data []byte
+
+	switch src := src.(type) {
+	case nil:
+		j.dest.SetZero()
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("cannot scan %T into a JSON field", src)
+	}
+
+	target := reflect.New(j.dest.Type())
+	if err := json.Unmarshal(data, target.Interface()); err != nil {
+		return err
+	}
+
+	j.dest.Set(target.Elem())
+
+	return nil
+}
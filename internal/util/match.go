@@ -0,0 +1,205 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FilterEvaluator is implemented by filters that can be applied to a model field in memory
+type FilterEvaluator interface {
+	Evaluate(value any) (Truth, error)
+}
+
+// RelationFilterEvaluator is implemented by relation filters, related holds the models loaded
+// on the relation's model field
+type RelationFilterEvaluator interface {
+	EvaluateRelated(related []any) (Truth, error)
+}
+
+// MatchWhere reports whether a query filtered by where would return model
+func MatchWhere(where any, model any) (bool, error) {
+	truth, err := EvaluateWhere(where, model)
+	return truth == TruthTrue, err
+}
+
+// EvaluateWhere applies where to model in memory with the same semantics as GetWhereSQL.
+// Filters are applied to the model field of the same name and relation filters to the related
+// models attached to the model, so those relations must be loaded.
+func EvaluateWhere(where any, model any) (Truth, error) {
+	rValue := reflect.ValueOf(where)
+
+	if rValue.Kind() == reflect.Pointer {
+		return TruthUnknown, errors.New("object is a pointer")
+	}
+
+	rModel := reflect.ValueOf(model)
+	if rModel.Kind() == reflect.Pointer {
+		if rModel.IsNil() {
+			return TruthUnknown, errors.New("model is nil")
+		}
+		rModel = rModel.Elem()
+	}
+
+	if rModel.Kind() != reflect.Struct {
+		return TruthUnknown, errors.New("model is not a struct")
+	}
+
+	rType := rValue.Type()
+	truth := TruthTrue
+
+	for i := 0; i < rValue.NumField(); i++ {
+		field := rValue.Field(i)
+		fieldT := rType.Field(i)
+
+		if strings.Contains(fieldT.Tag.Get("orm"), "ignore") {
+			continue
+		}
+
+		var fieldTruth Truth
+		var err error
+
+		if fieldT.Type.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+
+			if combinator, ok := whereCombinators[fieldT.Name]; ok && isWhereGroup(fieldT.Type, rType) {
+				fieldTruth, err = evaluateWhereGroup(combinator, field.Elem(), model)
+			} else if field.Type().Implements(reflect.TypeOf((*RelationFilterSQLer)(nil)).Elem()) {
+				fieldTruth, err = evaluateRelationFilter(rType, fieldT.Name, field, rModel)
+			} else if field.Type().Implements(reflect.TypeOf((*FilterSQLer)(nil)).Elem()) {
+				evaluator, ok := field.Interface().(FilterEvaluator)
+				if !ok {
+					return TruthUnknown, errors.New("filter on " + rType.String() + "." + fieldT.Name + " cannot be evaluated in memory")
+				}
+
+				var modelField reflect.Value
+				modelField, err = whereModelField(rModel, fieldT.Name)
+				if err == nil {
+					fieldTruth, err = evaluator.Evaluate(modelField.Interface())
+				}
+			} else if !isRelationType(fieldT.Type) {
+				fieldTruth, err = evaluateWhereEquals(rModel, fieldT.Name, field.Elem())
+			} else {
+				continue
+			}
+		} else if !(isRelationType(fieldT.Type) || field.Kind() == reflect.Slice || field.Kind() == reflect.Array) {
+			fieldTruth, err = evaluateWhereEquals(rModel, fieldT.Name, field)
+		} else {
+			continue
+		}
+
+		if err != nil {
+			return TruthUnknown, err
+		}
+
+		truth = truth.And(fieldTruth)
+	}
+
+	return truth, nil
+}
+
+func evaluateWhereGroup(combinator whereCombinator, group reflect.Value, model any) (Truth, error) {
+	if combinator == whereNot {
+		truth, err := EvaluateWhere(group.Interface(), model)
+		return truth.Not(), err
+	}
+
+	if group.Kind() != reflect.Slice {
+		return TruthUnknown, errors.New("where group is not a slice")
+	}
+
+	// an empty OR group matches nothing and an empty AND group matches everything
+	truth := TruthOf(combinator != whereOr)
+
+	for i := 0; i < group.Len(); i++ {
+		memberTruth, err := EvaluateWhere(group.Index(i).Interface(), model)
+		if err != nil {
+			return TruthUnknown, err
+		}
+
+		if combinator == whereOr {
+			truth = truth.Or(memberTruth)
+		} else {
+			truth = truth.And(memberTruth)
+		}
+	}
+
+	return truth, nil
+}
+
+func evaluateRelationFilter(whereType reflect.Type, name string, filter reflect.Value, model reflect.Value) (Truth, error) {
+	relation, ok := getWhereRelation(whereType, name)
+	if !ok {
+		return TruthUnknown, errors.New("no relation registered for " + whereType.String() + "." + name)
+	}
+
+	evaluator, ok := filter.Interface().(RelationFilterEvaluator)
+	if !ok {
+		return TruthUnknown, errors.New("relation filter on " + whereType.String() + "." + name + " cannot be evaluated in memory")
+	}
+
+	field, err := whereModelField(model, relation.ModelField())
+	if err != nil {
+		return TruthUnknown, err
+	}
+
+	related := []any{}
+
+	switch {
+	case field.Kind() == reflect.Pointer && field.IsNil(), field.Kind() == reflect.Slice && field.IsNil():
+		return TruthUnknown, errors.New("relation " + name + " is not loaded on " + model.Type().String())
+	case field.Kind() == reflect.Pointer && field.Elem().Kind() == reflect.Slice:
+		for i := 0; i < field.Elem().Len(); i++ {
+			related = append(related, field.Elem().Index(i).Interface())
+		}
+	case field.Kind() == reflect.Slice:
+		for i := 0; i < field.Len(); i++ {
+			related = append(related, field.Index(i).Interface())
+		}
+	default:
+		related = append(related, field.Interface())
+	}
+
+	return evaluator.EvaluateRelated(related)
+}
+
+// evaluateWhereEquals mirrors the column=? condition used for plain where fields
+func evaluateWhereEquals(model reflect.Value, name string, value reflect.Value) (Truth, error) {
+	field, err := whereModelField(model, name)
+	if err != nil {
+		return TruthUnknown, err
+	}
+
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return TruthUnknown, nil
+		}
+		field = field.Elem()
+	}
+
+	if fieldTime, ok := field.Interface().(time.Time); ok {
+		valueTime, ok := value.Interface().(time.Time)
+		return TruthOf(ok && fieldTime.Equal(valueTime)), nil
+	}
+
+	if value.Type() != field.Type() {
+		if !value.Type().ConvertibleTo(field.Type()) {
+			return TruthUnknown, errors.New("cannot compare " + value.Type().String() + " with model field " + name)
+		}
+		value = value.Convert(field.Type())
+	}
+
+	return TruthOf(reflect.DeepEqual(field.Interface(), value.Interface())), nil
+}
+
+func whereModelField(model reflect.Value, name string) (reflect.Value, error) {
+	field := model.FieldByName(name)
+	if !field.IsValid() {
+		return reflect.Value{}, errors.New("model " + model.Type().String() + " has no field " + name)
+	}
+
+	return field, nil
+}
@@ -1,12 +1,15 @@
 package util
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -19,8 +22,15 @@ func (x *NullString) MarshalJSON() ([]byte, error) {
 	return json.Marshal(x.String)
 }
 
+// Querier is satisfied by *sql.DB, *sql.Tx and *sql.Conn
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type FilterSQLer interface {
-	SQL(columnKey string) (string, []any)
+	SQL(columnKey string) (string, []any, error)
 }
 
 var structColumnsMap = make(map[string][]string)
@@ -48,7 +58,7 @@ func GetColumnsFromModel(obj any) ([]string, error) {
 		field := rElem.Field(i)
 		if field.CanSet() {
 			fieldT := rType.Field(i)
-			if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || fieldT.Type.Kind() == reflect.Struct || (fieldT.Type.Kind() == reflect.Pointer && (fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array)) {
+			if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || (isRelationType(fieldT.Type) && !isJSONField(fieldT)) {
 				continue
 			}
 			// convert pascal case to lower camel case
@@ -69,6 +79,60 @@ func GetColumnsFromModel(obj any) ([]string, error) {
 	return columnNames, nil
 }
 
+func GetColumnValues(obj any, columns []string) ([]any, error) {
+	rValue := reflect.Indirect(reflect.ValueOf(obj))
+
+	if rValue.Kind() != reflect.Struct {
+		return nil, errors.New("object is not a struct or pointer to a struct")
+	}
+
+	rType := rValue.Type()
+	fieldValues := map[string]any{}
+
+	for i := 0; i < rValue.NumField(); i++ {
+		if !rType.Field(i).IsExported() {
+			continue
+		}
+		fieldValues[toLowerCamelCase(rType.Field(i).Name)] = rValue.Field(i).Interface()
+	}
+
+	values := []any{}
+	for _, column := range columns {
+		value, ok := fieldValues[column]
+		if !ok {
+			return nil, errors.New("column " + column + " not found on " + rType.String())
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// isRelationType reports if a field holds related models rather than a column,
+// time.Time is a struct but is scanned as a column
+func isRelationType(fieldType reflect.Type) bool {
+	if fieldType == timeType || (fieldType.Kind() == reflect.Pointer && fieldType.Elem() == timeType) {
+		return false
+	}
+
+	return fieldType.Kind() == reflect.Struct || (fieldType.Kind() == reflect.Pointer && (fieldType.Elem().Kind() == reflect.Struct || fieldType.Elem().Kind() == reflect.Slice || fieldType.Elem().Kind() == reflect.Array))
+}
+
+// convert pascal case to lower camel case
+func toLowerCamelCase(name string) string {
+	lowerCamelCase := []rune{}
+	for j, r := range name {
+		if unicode.IsUpper(r) && j > 0 {
+			lowerCamelCase = append(lowerCamelCase, r)
+		} else {
+			lowerCamelCase = append(lowerCamelCase, unicode.ToLower(r))
+		}
+	}
+	return string(lowerCamelCase)
+}
+
 func GetCreateSQL(data any) ([]string, []any, error) {
 	columns := []string{}
 	params := []any{}
@@ -87,7 +151,7 @@ func GetCreateSQL(data any) ([]string, []any, error) {
 		field := rValue.Field(i)
 		fieldT := rType.Field(i)
 
-		if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || fieldT.Type.Kind() == reflect.Struct || (fieldT.Type.Kind() == reflect.Pointer && (fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array)) {
+		if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || (isRelationType(fieldT.Type) && !isJSONField(fieldT)) {
 			continue
 		}
 		// convert pascal case to lower camel case
@@ -99,15 +163,46 @@ func GetCreateSQL(data any) ([]string, []any, error) {
 				lowerCamelCase = append(lowerCamelCase, unicode.ToLower(r))
 			}
 		}
+		param, err := columnParam(field, fieldT)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		columns = append(columns, string(lowerCamelCase))
-		params = append(params, field.Interface())
+		params = append(params, param)
 
 	}
 
 	return columns, params, nil
 }
 
+// GetUpdateSQL builds the SET clause for data. Every field is written, so a nil pointer sets
+// its column to NULL and nullable columns can be cleared.
 func GetUpdateSQL(data any) (string, []any, error) {
+	return getUpdateSQL(data, nil)
+}
+
+// GetUpdateFieldsSQL builds the SET clause for the named fields of data only, so a partial
+// update leaves the other columns as they are. A nil pointer in a named field sets it to NULL.
+func GetUpdateFieldsSQL(data any, fields []string) (string, []any, error) {
+	rType := reflect.TypeOf(data)
+	if rType != nil && rType.Kind() == reflect.Struct {
+		for _, name := range fields {
+			if _, ok := rType.FieldByName(name); !ok {
+				return "", nil, errors.New(rType.String() + " has no field " + name)
+			}
+		}
+	}
+
+	if fields == nil {
+		fields = []string{}
+	}
+
+	return getUpdateSQL(data, fields)
+}
+
+// getUpdateSQL writes the fields named in fields, or every field when fields is nil
+func getUpdateSQL(data any, fields []string) (string, []any, error) {
 	sql := []string{}
 	params := []any{}
 
@@ -125,7 +220,10 @@ func GetUpdateSQL(data any) (string, []any, error) {
 		field := rValue.Field(i)
 		fieldT := rType.Field(i)
 
-		if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || fieldT.Type.Kind() == reflect.Struct || (fieldT.Type.Kind() == reflect.Pointer && (fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array)) {
+		if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || (isRelationType(fieldT.Type) && !isJSONField(fieldT)) {
+			continue
+		}
+		if fields != nil && !slices.Contains(fields, fieldT.Name) {
 			continue
 		}
 		// convert pascal case to lower camel case
@@ -137,8 +235,13 @@ func GetUpdateSQL(data any) (string, []any, error) {
 				lowerCamelCase = append(lowerCamelCase, unicode.ToLower(r))
 			}
 		}
+		param, err := columnParam(field, fieldT)
+		if err != nil {
+			return "", nil, err
+		}
+
 		sql = append(sql, string(lowerCamelCase)+"=?")
-		params = append(params, field.Interface())
+		params = append(params, param)
 
 	}
 
@@ -183,27 +286,145 @@ func GetWhereSQL(data any) (string, []any, error) {
 				continue
 			}
 
-			if field.Type().Implements(reflect.TypeOf((*FilterSQLer)(nil)).Elem()) {
-				filterSql, filterParams := field.Interface().(FilterSQLer).SQL(string(lowerCamelCase))
+			if combinator, ok := whereCombinators[fieldT.Name]; ok && isWhereGroup(fieldT.Type, rType) {
+				groupSql, groupParams, err := getWhereGroupSQL(combinator, field.Elem())
+				if err != nil {
+					return "", nil, err
+				}
+
+				if groupSql != "" {
+					sql = append(sql, groupSql)
+					params = append(params, groupParams...)
+				}
+			} else if field.Type().Implements(reflect.TypeOf((*RelationFilterSQLer)(nil)).Elem()) {
+				relation, ok := getWhereRelation(rType, fieldT.Name)
+				if !ok {
+					return "", nil, errors.New("no relation registered for " + rType.String() + "." + fieldT.Name)
+				}
 
-				sql = append(sql, filterSql)
-				params = append(params, filterParams...)
+				relationSql, relationParams, err := field.Interface().(RelationFilterSQLer).RelationSQL(relation)
+				if err != nil {
+					return "", nil, err
+				}
+
+				if relationSql != "" {
+					sql = append(sql, relationSql)
+					params = append(params, relationParams...)
+				}
+			} else if field.Type().Implements(reflect.TypeOf((*FilterSQLer)(nil)).Elem()) {
+				filterSql, filterParams, err := field.Interface().(FilterSQLer).SQL(string(lowerCamelCase))
+				if err != nil {
+					return "", nil, err
+				}
+
+				if filterSql != "" {
+					sql = append(sql, filterSql)
+					params = append(params, filterParams...)
+				}
 			} else {
-				if !(fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array) {
+				if !isRelationType(fieldT.Type) {
 					sql = append(sql, string(lowerCamelCase)+"=?")
 					params = append(params, field.Interface())
 				}
 			}
-		} else if !(field.Kind() == reflect.Struct || field.Kind() == reflect.Slice || field.Kind() == reflect.Array) {
+		} else if !(isRelationType(fieldT.Type) || field.Kind() == reflect.Slice || field.Kind() == reflect.Array) {
 			sql = append(sql, string(lowerCamelCase)+"=?")
 			params = append(params, field.Interface())
 		}
 	}
 
-	return strings.Join(sql, ", "), params, nil
+	return strings.Join(sql, " AND "), params, nil
+}
+
+type whereCombinator int
+
+const (
+	whereAnd whereCombinator = iota
+	whereOr
+	whereNot
+)
+
+// where structs combine nested where structs of their own type through these fields:
+// And *[]T, Or *[]T and Not *T
+var whereCombinators = map[string]whereCombinator{
+	"And": whereAnd,
+	"Or":  whereOr,
+	"Not": whereNot,
+}
+
+func isWhereGroup(fieldType reflect.Type, whereType reflect.Type) bool {
+	elemType := fieldType.Elem()
+	if elemType.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+
+	return elemType == whereType
 }
 
-func ScanRow(db *sql.DB, dest any, query string, args ...any) error {
+func getWhereGroupSQL(combinator whereCombinator, group reflect.Value) (string, []any, error) {
+	if combinator == whereNot {
+		notSql, notParams, err := GetWhereSQL(group.Interface())
+		if err != nil {
+			return "", nil, err
+		}
+
+		// an empty where matches every row, so its negation matches none
+		if notSql == "" {
+			return "1 = 0", []any{}, nil
+		}
+
+		return "NOT (" + notSql + ")", notParams, nil
+	}
+
+	if group.Kind() != reflect.Slice {
+		return "", nil, errors.New("where group is not a slice")
+	}
+
+	if group.Len() == 0 {
+		if combinator == whereOr {
+			return "1 = 0", []any{}, nil
+		}
+		return "", []any{}, nil
+	}
+
+	individualSQLStrings := []string{}
+	individualParameters := []any{}
+
+	for i := 0; i < group.Len(); i++ {
+		memberSql, memberParams, err := GetWhereSQL(group.Index(i).Interface())
+		if err != nil {
+			return "", nil, err
+		}
+
+		if memberSql == "" {
+			// an empty member matches every row, which makes the whole OR group match every row
+			if combinator == whereOr {
+				return "", []any{}, nil
+			}
+			continue
+		}
+
+		individualSQLStrings = append(individualSQLStrings, "("+memberSql+")")
+		individualParameters = append(individualParameters, memberParams...)
+	}
+
+	if len(individualSQLStrings) == 0 {
+		return "", []any{}, nil
+	}
+
+	separator := " AND "
+	if combinator == whereOr {
+		separator = " OR "
+	}
+
+	return "(" + strings.Join(individualSQLStrings, separator) + ")", individualParameters, nil
+}
+
+func ScanRow(db Querier, dest any, query string, args ...any) error {
+	return ScanRowContext(context.Background(), db, dest, query, args...)
+}
+
+func ScanRowContext(ctx context.Context, db Querier, dest any, query string, args ...any) error {
 	rValue := reflect.ValueOf(dest)
 
 	if rValue.Kind() != reflect.Pointer {
@@ -216,7 +437,7 @@ func ScanRow(db *sql.DB, dest any, query string, args ...any) error {
 		return errors.New("destination is not a pointer to a struct")
 	}
 
-	row := db.QueryRow(query, args...)
+	row := db.QueryRowContext(ctx, query, args...)
 
 	numFields := rElem.NumField()
 	scanArgs := []any{}
@@ -225,11 +446,11 @@ func ScanRow(db *sql.DB, dest any, query string, args ...any) error {
 		field := rElem.Field(i)
 		if field.CanSet() {
 			fieldT := rElemType.Field(i)
-			if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || fieldT.Type.Kind() == reflect.Struct || (fieldT.Type.Kind() == reflect.Pointer && (fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array)) {
+			if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || (isRelationType(fieldT.Type) && !isJSONField(fieldT)) {
 				continue
 			}
 
-			scanArgs = append(scanArgs, field.Addr().Interface())
+			scanArgs = append(scanArgs, scanTarget(field, fieldT))
 		}
 	}
 
@@ -241,7 +462,11 @@ func ScanRow(db *sql.DB, dest any, query string, args ...any) error {
 	return nil
 }
 
-func ScanRows(db *sql.DB, dest any, query string, args ...any) error {
+func ScanRows(db Querier, dest any, query string, args ...any) error {
+	return ScanRowsContext(context.Background(), db, dest, query, args...)
+}
+
+func ScanRowsContext(ctx context.Context, db Querier, dest any, query string, args ...any) error {
 	rValue := reflect.ValueOf(dest)
 
 	if rValue.Kind() != reflect.Pointer {
@@ -258,7 +483,7 @@ func ScanRows(db *sql.DB, dest any, query string, args ...any) error {
 		return errors.New("destination is not a pointer to an slice of structs")
 	}
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -276,11 +501,11 @@ func ScanRows(db *sql.DB, dest any, query string, args ...any) error {
 
 			if field.CanSet() {
 				fieldT := rElemType.Field(i)
-				if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || fieldT.Type.Kind() == reflect.Struct || (fieldT.Type.Kind() == reflect.Pointer && (fieldT.Type.Elem().Kind() == reflect.Struct || fieldT.Type.Elem().Kind() == reflect.Slice || fieldT.Type.Elem().Kind() == reflect.Array)) {
+				if strings.Contains(fieldT.Tag.Get("orm"), "ignore") || (isRelationType(fieldT.Type) && !isJSONField(fieldT)) {
 					continue
 				}
 
-				scanArgs = append(scanArgs, field.Addr().Interface())
+				scanArgs = append(scanArgs, scanTarget(field, fieldT))
 			}
 		}
 
@@ -0,0 +1,530 @@
+package util_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/util"
+)
+
+type testStatus string
+
+const (
+	testStatusDraft     testStatus = "draft"
+	testStatusPublished testStatus = "published"
+)
+
+func init() {
+	filters.DeclareEnum(testStatusDraft, testStatusPublished)
+	util.RegisterWhereRelation(reflect.TypeFor[testWhere](), "Tags", testTagsRelation{})
+}
+
+type testTag struct {
+	Label *string
+}
+
+type testTagWhere struct {
+	Label *filters.StringFilter
+}
+
+func (w testTagWhere) SQL() (string, []any, error) {
+	return util.GetWhereSQL(w)
+}
+
+type testTagsRelation struct{}
+
+func (testTagsRelation) ExistsSQL(where string) string {
+	if where != "" {
+		where = " AND " + where
+	}
+	return "EXISTS (SELECT 1 FROM tags WHERE tags.ownerId = owners.id" + where + ")"
+}
+
+func (testTagsRelation) ModelField() string {
+	return "Tags"
+}
+
+type testModel struct {
+	Id        int
+	Email     *string
+	CreatedAt *time.Time
+	Rating    *float64
+	Price     *big.Rat
+	Status    testStatus
+	Tags      *[]testTag
+}
+
+// every where case is also evaluated in memory against these models, the second has an empty
+// relation and the third has NULL columns
+var testModels = []testModel{
+	{
+		Id:        2,
+		Email:     util.ToPointer("a"),
+		CreatedAt: util.ToPointer(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)),
+		Rating:    util.ToPointer(3.0),
+		Price:     big.NewRat(1999, 100),
+		Status:    testStatusDraft,
+		Tags:      &[]testTag{{Label: util.ToPointer("go")}, {Label: util.ToPointer("sql")}},
+	},
+	{
+		Id:        7,
+		Email:     util.ToPointer("Admin50%_off!x"),
+		CreatedAt: util.ToPointer(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)),
+		Price:     big.NewRat(5, 1),
+		Status:    testStatusPublished,
+		Tags:      &[]testTag{},
+	},
+	{
+		Id:     12,
+		Rating: util.ToPointer(4.5),
+		Status: testStatusDraft,
+		Tags:   &[]testTag{{}},
+	},
+}
+
+type testWhere struct {
+	Id        *filters.IntFilter
+	Email     *filters.StringFilter
+	CreatedAt *filters.TimeFilter
+	Rating    *filters.FloatFilter
+	Price     *filters.DecimalFilter
+	Status    *filters.EnumFilter[testStatus]
+	Tags      *filters.RelationFilter[testTagWhere]
+
+	And *[]testWhere
+	Or  *[]testWhere
+	Not *testWhere
+}
+
+func TestGetWhereSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		where  testWhere
+		sql    string
+		params []any
+		// expected result for each of testModels
+		truths []util.Truth
+	}{
+		{
+			name:   "empty",
+			where:  testWhere{},
+			sql:    "",
+			params: []any{},
+			truths: []util.Truth{util.TruthTrue, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name:   "single field",
+			where:  testWhere{Email: filters.StrEquals("a@b.com")},
+			sql:    "email = ?",
+			params: []any{"a@b.com"},
+			truths: []util.Truth{util.TruthFalse, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "fields are joined with AND",
+			where:  testWhere{Id: filters.IntLessThan(10), Email: filters.StrContains("x")},
+			sql:    "id < ? AND email LIKE ? ESCAPE '!'",
+			params: []any{"10", "%x%"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthFalse},
+		},
+		{
+			name:   "every operator of a filter is applied",
+			where:  testWhere{Id: &filters.IntFilter{GreaterThan: util.ToPointer(5), LessThan: util.ToPointer(10)}},
+			sql:    "(id < ? AND id > ?)",
+			params: []any{"10", "5"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthFalse},
+		},
+		{
+			name:   "time equals inside the range",
+			where:  testWhere{CreatedAt: &filters.TimeFilter{Equals: util.ToPointer(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)), After: util.ToPointer(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))}},
+			sql:    "(createdAt = ? AND createdAt > ?)",
+			params: []any{"2024-05-15 00:00:00", "2024-05-01 00:00:00"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "inclusive bounds on the same value",
+			where:  testWhere{Id: &filters.IntFilter{GreaterThanOrEqualTo: util.ToPointer(7), LessThanOrEqualTo: util.ToPointer(7)}},
+			sql:    "(id <= ? AND id >= ?)",
+			params: []any{"7", "7"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthFalse},
+		},
+		{
+			name:   "float range",
+			where:  testWhere{Rating: filters.FloatBetween(2.5, 4.5)},
+			sql:    "rating BETWEEN ? AND ?",
+			params: []any{2.5, 4.5},
+			truths: []util.Truth{util.TruthTrue, util.TruthUnknown, util.TruthTrue},
+		},
+		{
+			name:   "decimal values are passed exactly",
+			where:  testWhere{Price: filters.DecimalGreaterThanOrEqualTo(big.NewRat(1999, 100))},
+			sql:    "price >= CAST(? AS DECIMAL(65, 30))",
+			params: []any{"19.99"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "decimal in casts every member",
+			where:  testWhere{Price: filters.DecimalIn([]*big.Rat{big.NewRat(1999, 100), big.NewRat(7, 1)})},
+			sql:    "price IN (CAST(? AS DECIMAL(65, 30)), CAST(? AS DECIMAL(65, 30)))",
+			params: []any{"19.99", "7"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "decimal not in casts every member",
+			where:  testWhere{Price: filters.DecimalNotIn([]*big.Rat{big.NewRat(5, 1)})},
+			sql:    "price NOT IN (CAST(? AS DECIMAL(65, 30)))",
+			params: []any{"5"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "enum in",
+			where:  testWhere{Status: filters.EnumIn([]testStatus{testStatusDraft, testStatusPublished})},
+			sql:    "status IN (?, ?)",
+			params: []any{"draft", "published"},
+			truths: []util.Truth{util.TruthTrue, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name:   "like wildcards in values are escaped",
+			where:  testWhere{Email: filters.StrContains("50%_off!")},
+			sql:    "email LIKE ? ESCAPE '!'",
+			params: []any{"%50!%!_off!!%"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthUnknown},
+		},
+		{
+			name:   "case insensitive starts with",
+			where:  testWhere{Email: &filters.StringFilter{StartsWith: util.ToPointer("Admin"), CaseInsensitive: true}},
+			sql:    "LOWER(email) LIKE ? ESCAPE '!'",
+			params: []any{"admin%"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthUnknown},
+		},
+		{
+			name: "or across fields",
+			where: testWhere{Or: &[]testWhere{
+				{Email: filters.StrContains("x")},
+				{Id: filters.IntLessThan(10)},
+			}},
+			sql:    "((email LIKE ? ESCAPE '!') OR (id < ?))",
+			params: []any{"%x%", "10"},
+			truths: []util.Truth{util.TruthTrue, util.TruthTrue, util.TruthUnknown},
+		},
+		{
+			name: "and group keeps members parenthesized",
+			where: testWhere{And: &[]testWhere{
+				{Id: filters.IntGreaterThan(1), Email: filters.StrEquals("a")},
+				{Id: filters.IntLessThan(5)},
+			}},
+			sql:    "((id > ? AND email = ?) AND (id < ?))",
+			params: []any{"1", "a", "5"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthFalse},
+		},
+		{
+			name:   "not",
+			where:  testWhere{Not: &testWhere{Email: filters.StrEquals("a"), Id: filters.IntEquals(2)}},
+			sql:    "NOT (id = ? AND email = ?)",
+			params: []any{"2", "a"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name: "fields and groups keep parameter order",
+			where: testWhere{
+				Id: filters.IntGreaterThan(3),
+				Or: &[]testWhere{
+					{Email: filters.StrEquals("a")},
+					{Not: &testWhere{Id: filters.IntEquals(7)}},
+				},
+				Not: &testWhere{Email: filters.StrContains("spam")},
+			},
+			sql:    "id > ? AND ((email = ?) OR (NOT (id = ?))) AND NOT (email LIKE ? ESCAPE '!')",
+			params: []any{"3", "a", "7", "%spam%"},
+			truths: []util.Truth{util.TruthFalse, util.TruthFalse, util.TruthUnknown},
+		},
+		{
+			name:   "empty or matches nothing",
+			where:  testWhere{Or: &[]testWhere{}},
+			sql:    "1 = 0",
+			params: []any{},
+			truths: []util.Truth{util.TruthFalse, util.TruthFalse, util.TruthFalse},
+		},
+		{
+			name:   "or with an empty member matches everything",
+			where:  testWhere{Or: &[]testWhere{{Id: filters.IntEquals(1)}, {}}},
+			sql:    "",
+			params: []any{},
+			truths: []util.Truth{util.TruthTrue, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name:   "empty and matches everything",
+			where:  testWhere{And: &[]testWhere{{}}},
+			sql:    "",
+			params: []any{},
+			truths: []util.Truth{util.TruthTrue, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name:   "not of empty matches nothing",
+			where:  testWhere{Not: &testWhere{}},
+			sql:    "1 = 0",
+			params: []any{},
+			truths: []util.Truth{util.TruthFalse, util.TruthFalse, util.TruthFalse},
+		},
+		{
+			name:   "some related row matches",
+			where:  testWhere{Tags: filters.RelationSome(testTagWhere{Label: filters.StrEquals("go")})},
+			sql:    "(EXISTS (SELECT 1 FROM tags WHERE tags.ownerId = owners.id AND label = ?))",
+			params: []any{"go"},
+			truths: []util.Truth{util.TruthTrue, util.TruthFalse, util.TruthFalse},
+		},
+		{
+			name:   "every related row matches",
+			where:  testWhere{Tags: filters.RelationEvery(testTagWhere{Label: filters.StrEquals("go")})},
+			sql:    "(NOT EXISTS (SELECT 1 FROM tags WHERE tags.ownerId = owners.id AND NOT (label = ?)))",
+			params: []any{"go"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthTrue},
+		},
+		{
+			name:   "no related row matches",
+			where:  testWhere{Tags: filters.RelationNone(testTagWhere{Label: filters.StrEquals("go")})},
+			sql:    "(NOT EXISTS (SELECT 1 FROM tags WHERE tags.ownerId = owners.id AND label = ?))",
+			params: []any{"go"},
+			truths: []util.Truth{util.TruthFalse, util.TruthTrue, util.TruthTrue},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sql, params, err := util.GetWhereSQL(test.where)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if sql != test.sql {
+				t.Errorf("sql = %q, want %q", sql, test.sql)
+			}
+
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("params = %#v, want %#v", params, test.params)
+			}
+
+			for i, model := range testModels {
+				truth, err := util.EvaluateWhere(test.where, model)
+				if err != nil {
+					t.Fatalf("model %d: unexpected error: %v", i, err)
+				}
+
+				if truth != test.truths[i] {
+					t.Errorf("model %d: truth = %v, want %v", i, truth, test.truths[i])
+				}
+			}
+		})
+	}
+}
+
+var (
+	_ util.FilterSQLer = (*filters.IntFilter)(nil)
+	_ util.FilterSQLer = (*filters.StringFilter)(nil)
+	_ util.FilterSQLer = (*filters.BoolFilter)(nil)
+	_ util.FilterSQLer = (*filters.TimeFilter)(nil)
+	_ util.FilterSQLer = (*filters.FloatFilter)(nil)
+	_ util.FilterSQLer = (*filters.DecimalFilter)(nil)
+	_ util.FilterSQLer = (*filters.EnumFilter[testStatus])(nil)
+	_ util.FilterSQLer = (*filters.JSONFilter)(nil)
+
+	_ util.FilterEvaluator = (*filters.IntFilter)(nil)
+	_ util.FilterEvaluator = (*filters.StringFilter)(nil)
+	_ util.FilterEvaluator = (*filters.BoolFilter)(nil)
+	_ util.FilterEvaluator = (*filters.TimeFilter)(nil)
+	_ util.FilterEvaluator = (*filters.FloatFilter)(nil)
+	_ util.FilterEvaluator = (*filters.DecimalFilter)(nil)
+	_ util.FilterEvaluator = (*filters.EnumFilter[testStatus])(nil)
+	_ util.FilterEvaluator = (*filters.JSONFilter)(nil)
+
+	_ util.RelationFilterEvaluator = (*filters.RelationFilter[testTagWhere])(nil)
+)
+
+func TestGetWhereSQLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		where testWhere
+	}{
+		{
+			name:  "empty filter",
+			where: testWhere{Id: &filters.IntFilter{}},
+		},
+		{
+			name:  "is null with a value comparison",
+			where: testWhere{Id: &filters.IntFilter{Equals: util.ToPointer(1), IsNull: util.ToPointer(true)}},
+		},
+		{
+			name:  "is null with a group",
+			where: testWhere{Id: &filters.IntFilter{IsNull: util.ToPointer(true), Or: &[]*filters.IntFilter{filters.IntEquals(1), filters.IntEquals(2)}}},
+		},
+		{
+			name:  "int greater than above less than",
+			where: testWhere{Id: &filters.IntFilter{GreaterThan: util.ToPointer(10), LessThan: util.ToPointer(5)}},
+		},
+		{
+			name:  "int equals outside the range",
+			where: testWhere{Id: &filters.IntFilter{Equals: util.ToPointer(20), Between: &[2]int{1, 10}}},
+		},
+		{
+			name:  "int strict bounds on the same value",
+			where: testWhere{Id: &filters.IntFilter{GreaterThan: util.ToPointer(5), LessThanOrEqualTo: util.ToPointer(5)}},
+		},
+		{
+			name:  "time equals after before",
+			where: testWhere{CreatedAt: &filters.TimeFilter{Equals: util.ToPointer(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)), Before: util.ToPointer(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))}},
+		},
+		{
+			name:  "time equals before after",
+			where: testWhere{CreatedAt: &filters.TimeFilter{Equals: util.ToPointer(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)), After: util.ToPointer(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))}},
+		},
+		{
+			name:  "time after equal to before",
+			where: testWhere{CreatedAt: &filters.TimeFilter{After: util.ToPointer(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)), Before: util.ToPointer(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))}},
+		},
+		{
+			name:  "time inverted between",
+			where: testWhere{CreatedAt: filters.TimeBetween(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			name:  "string inverted between",
+			where: testWhere{Email: filters.StrBetween("m", "a")},
+		},
+		{
+			name:  "string inverted not between",
+			where: testWhere{Email: &filters.StringFilter{NotBetween: &[2]string{"m", "a"}}},
+		},
+		{
+			name:  "string equals outside between",
+			where: testWhere{Email: &filters.StringFilter{Equals: util.ToPointer("z"), Between: &[2]string{"a", "m"}}},
+		},
+		{
+			name:  "float less than below greater than or equal to",
+			where: testWhere{Rating: &filters.FloatFilter{GreaterThanOrEqualTo: util.ToPointer(4.5), LessThan: util.ToPointer(2.5)}},
+		},
+		{
+			name:  "float equals outside the range",
+			where: testWhere{Rating: &filters.FloatFilter{Equals: util.ToPointer(1.0), GreaterThan: util.ToPointer(2.0)}},
+		},
+		{
+			name:  "equals and is not the same value",
+			where: testWhere{Email: &filters.StringFilter{Equals: util.ToPointer("a"), IsNot: util.ToPointer("a")}},
+		},
+		{
+			name:  "inverted between",
+			where: testWhere{Id: filters.IntBetween(10, 1)},
+		},
+		{
+			name:  "decimal without an exact representation",
+			where: testWhere{Price: filters.DecimalEquals(big.NewRat(1, 3))},
+		},
+		{
+			name:  "unknown enum value",
+			where: testWhere{Status: filters.EnumEquals(testStatus("archived"))},
+		},
+		{
+			name:  "empty or list",
+			where: testWhere{Id: filters.IntOr([]*filters.IntFilter{})},
+		},
+		{
+			name:  "error inside a where group",
+			where: testWhere{Or: &[]testWhere{{Id: filters.IntEquals(1)}, {Email: &filters.StringFilter{}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := util.GetWhereSQL(test.where)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if _, err := util.EvaluateWhere(test.where, testModels[0]); err == nil {
+				t.Fatal("expected an error from in-memory evaluation")
+			}
+		})
+	}
+}
+
+type testUpdate struct {
+	Label    *string
+	Cover    *string
+	Count    int
+	Metadata *map[string]any `orm:"json"`
+	Ignored  *string         `orm:"ignore"`
+}
+
+func TestGetUpdateSQL(t *testing.T) {
+	var nilMetadata map[string]any
+
+	cases := []struct {
+		name   string
+		data   testUpdate
+		sql    string
+		params []any
+	}{
+		{"nil pointers clear their columns", testUpdate{Label: util.ToPointer("a"), Count: 2}, "label=?, cover=?, count=?, metadata=?", []any{"a", nil, 2, nil}},
+		{"zero values are written", testUpdate{}, "label=?, cover=?, count=?, metadata=?", []any{nil, nil, 0, nil}},
+		{"json value", testUpdate{Metadata: &map[string]any{"a": 1}}, "label=?, cover=?, count=?, metadata=?", []any{nil, nil, 0, `{"a":1}`}},
+		{"pointer to a nil json map", testUpdate{Metadata: &nilMetadata}, "label=?, cover=?, count=?, metadata=?", []any{nil, nil, 0, nil}},
+		{"ignored field", testUpdate{Ignored: util.ToPointer("x")}, "label=?, cover=?, count=?, metadata=?", []any{nil, nil, 0, nil}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, params, err := util.GetUpdateSQL(c.data)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// compare what the driver is sent, which dereferences pointers
+			for i, param := range params {
+				if value := reflect.ValueOf(param); value.Kind() == reflect.Pointer {
+					params[i] = nil
+					if !value.IsNil() {
+						params[i] = value.Elem().Interface()
+					}
+				}
+			}
+
+			if sql != c.sql {
+				t.Errorf("sql = %q, want %q", sql, c.sql)
+			}
+
+			if !reflect.DeepEqual(params, c.params) {
+				t.Errorf("params = %#v, want %#v", params, c.params)
+			}
+		})
+	}
+}
+
+func TestGetUpdateFieldsSQL(t *testing.T) {
+	sql, params, err := util.GetUpdateFieldsSQL(testUpdate{Label: util.ToPointer("a"), Count: 2}, []string{"Cover", "Label"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// fields keep the struct order and a nil pointer still clears its column
+	if want := "label=?, cover=?"; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+
+	if label, ok := params[0].(*string); !ok || *label != "a" || params[1] != (*string)(nil) {
+		t.Errorf("params = %#v", params)
+	}
+
+	if _, _, err := util.GetUpdateFieldsSQL(testUpdate{}, []string{"Missing"}); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestEvaluateWhereRequiresLoadedRelations(t *testing.T) {
+	where := testWhere{Tags: filters.RelationSome(testTagWhere{})}
+
+	if _, err := util.EvaluateWhere(where, testModel{Id: 1}); err == nil {
+		t.Fatal("expected an error for a relation that is not loaded")
+	}
+}
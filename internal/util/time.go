@@ -0,0 +1,90 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var timeLocation = time.UTC
+
+// SetTimeLocation sets the location timestamps are read in when the driver returns them as
+// text, and the location scanned times are converted to. Defaults to UTC.
+func SetTimeLocation(location *time.Location) {
+	timeLocation = location
+}
+
+func GetTimeLocation() *time.Location {
+	return timeLocation
+}
+
+// TimeParam formats value as a timestamp in the configured location for binding as a query
+// parameter, the driver would otherwise encode a time.Time in the location it is configured with
+func TimeParam(value time.Time) string {
+	return value.In(timeLocation).Format("2006-01-02 15:04:05.999999")
+}
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// timeScanner scans timestamp columns into time.Time and *time.Time fields whether or not the
+// driver is configured to parse times itself
+type timeScanner struct {
+	dest reflect.Value
+}
+
+func (t timeScanner) Scan(src any) error {
+	if src == nil {
+		t.dest.SetZero()
+		return nil
+	}
+
+	var value time.Time
+
+	switch src := src.(type) {
+	case time.Time:
+		value = src.In(timeLocation)
+	case []byte:
+		parsed, err := parseTime(string(src))
+		if err != nil {
+			return err
+		}
+		value = parsed
+	case string:
+		parsed, err := parseTime(src)
+		if err != nil {
+			return err
+		}
+		value = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+
+	if t.dest.Kind() == reflect.Pointer {
+		t.dest.Set(reflect.ValueOf(&value))
+	} else {
+		t.dest.Set(reflect.ValueOf(value))
+	}
+
+	return nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		parsed, err := time.ParseInLocation(layout, value, timeLocation)
+		if err == nil {
+			return parsed.In(timeLocation), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", value)
+}
+
+// ValueScanTarget returns what to pass to Scan for the value dest points to, so single values
+// such as aggregates are read like model fields
+func ValueScanTarget(dest any) any {
+	return scanTarget(reflect.ValueOf(dest).Elem(), reflect.StructField{})
+}
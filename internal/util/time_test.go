@@ -0,0 +1,116 @@
+package util_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"smithsolutions/go-api/internal/filters"
+	"smithsolutions/go-api/internal/util"
+)
+
+func TestScanTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data is not available")
+	}
+
+	noon := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		location *time.Location
+		src      any
+		want     time.Time
+	}{
+		{"time value", time.UTC, noon, noon},
+		{"time value is converted", newYork, noon, noon.In(newYork)},
+		{"datetime bytes", time.UTC, []byte("2024-05-01 12:30:00"), noon},
+		{"datetime string", time.UTC, "2024-05-01 12:30:00", noon},
+		{"fractional seconds", time.UTC, "2024-05-01 12:30:00.250", noon.Add(250 * time.Millisecond)},
+		{"RFC 3339", time.UTC, "2024-05-01T14:30:00+02:00", noon},
+		{"date", time.UTC, []byte("2024-05-01"), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"text is read in the location", newYork, "2024-05-01 08:30:00", noon},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			util.SetTimeLocation(c.location)
+			t.Cleanup(func() { util.SetTimeLocation(time.UTC) })
+
+			var value time.Time
+			if err := util.ValueScanTarget(&value).(sql.Scanner).Scan(c.src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !value.Equal(c.want) || value.Location() != c.location {
+				t.Errorf("time.Time = %v, want %v in %v", value, c.want, c.location)
+			}
+
+			var pointer *time.Time
+			if err := util.ValueScanTarget(&pointer).(sql.Scanner).Scan(c.src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if pointer == nil || !pointer.Equal(c.want) || pointer.Location() != c.location {
+				t.Errorf("*time.Time = %v, want %v in %v", pointer, c.want, c.location)
+			}
+		})
+	}
+}
+
+func TestScanTimeNull(t *testing.T) {
+	value := time.Now()
+	if err := util.ValueScanTarget(&value).(sql.Scanner).Scan(nil); err != nil || !value.IsZero() {
+		t.Errorf("time.Time = %v, %v, want the zero time", value, err)
+	}
+
+	pointer := &value
+	if err := util.ValueScanTarget(&pointer).(sql.Scanner).Scan(nil); err != nil || pointer != nil {
+		t.Errorf("*time.Time = %v, %v, want nil", pointer, err)
+	}
+}
+
+func TestScanTimeErrors(t *testing.T) {
+	for _, src := range []any{"yesterday", []byte("2024-13-01 00:00:00"), int64(1714566600)} {
+		var value time.Time
+		if err := util.ValueScanTarget(&value).(sql.Scanner).Scan(src); err == nil {
+			t.Errorf("expected an error scanning %#v, got %v", src, value)
+		}
+	}
+}
+
+func TestTimeFilterParams(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data is not available")
+	}
+
+	util.SetTimeLocation(newYork)
+	t.Cleanup(func() { util.SetTimeLocation(time.UTC) })
+
+	// params are bound in the configured location whatever location the driver encodes times in
+	noon := time.Date(2024, 5, 1, 12, 30, 0, 250000000, time.UTC)
+
+	cases := []struct {
+		name   string
+		filter *filters.TimeFilter
+		sql    string
+		params []any
+	}{
+		{"equals", filters.TimeEquals(noon), "createdAt = ?", []any{"2024-05-01 08:30:00.25"}},
+		{"between", filters.TimeBetween(noon, noon.Add(time.Hour)), "createdAt BETWEEN ? AND ?", []any{"2024-05-01 08:30:00.25", "2024-05-01 09:30:00.25"}},
+		{"on date", filters.TimeOnDate(noon), "(createdAt >= ? AND createdAt < ?)", []any{"2024-05-01 00:00:00", "2024-05-02 00:00:00"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sql, params, err := c.filter.SQL("createdAt")
+
+			if err != nil || sql != c.sql || !reflect.DeepEqual(params, c.params) {
+				t.Errorf("got %q %v %v, want %q %v", sql, params, err, c.sql, c.params)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package util
+
+// Truth is a SQL boolean, comparisons against NULL are neither true nor false
+type Truth int
+
+const (
+	TruthFalse Truth = iota
+	TruthTrue
+	TruthUnknown
+)
+
+func TruthOf(value bool) Truth {
+	if value {
+		return TruthTrue
+	}
+	return TruthFalse
+}
+
+func (t Truth) And(other Truth) Truth {
+	if t == TruthFalse || other == TruthFalse {
+		return TruthFalse
+	}
+	if t == TruthUnknown || other == TruthUnknown {
+		return TruthUnknown
+	}
+	return TruthTrue
+}
+
+func (t Truth) Or(other Truth) Truth {
+	if t == TruthTrue || other == TruthTrue {
+		return TruthTrue
+	}
+	if t == TruthUnknown || other == TruthUnknown {
+		return TruthUnknown
+	}
+	return TruthFalse
+}
+
+func (t Truth) Not() Truth {
+	switch t {
+	case TruthTrue:
+		return TruthFalse
+	case TruthFalse:
+		return TruthTrue
+	}
+	return TruthUnknown
+}
+
+func (t Truth) String() string {
+	switch t {
+	case TruthTrue:
+		return "TRUE"
+	case TruthFalse:
+		return "FALSE"
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,196 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is a rule from a validate tag that a field did not pass
+type ValidationError struct {
+	// lower camel case name of the field, matching its column
+	Field   string
+	Message string
+}
+
+// ValidationErrors lists every failed rule of a struct in field order
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := []string{}
+	for _, validationErr := range e {
+		messages = append(messages, validationErr.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Validate checks data against the validate tags on its fields, for example
+//
+//	Email string `validate:"required,email,max=255"`
+//
+// Rules are comma separated:
+//
+//	required  the value must be set, strings must not be blank
+//	email     a plain address such as name@example.com, without a display name
+//	min=N     strings must have at least N characters, slices and maps N items, numbers be >= N
+//	max=N     strings must have at most N characters, slices and maps N items, numbers be <= N
+//
+// Strings are counted in characters like VARCHAR limits. A nil pointer skips every rule but
+// required, so optional update fields are only checked when given. Failed rules are returned
+// as ValidationErrors, a malformed tag returns a plain error.
+func Validate(data any) error {
+	return validate(data, nil)
+}
+
+// ValidateFields is Validate for the named fields of data only, so a partial update is not
+// held to the rules of fields it leaves out
+func ValidateFields(data any, fields []string) error {
+	if fields == nil {
+		fields = []string{}
+	}
+	return validate(data, fields)
+}
+
+// validate checks the fields named in fields, or every field when fields is nil
+func validate(data any, fields []string) error {
+	rValue := reflect.Indirect(reflect.ValueOf(data))
+
+	if rValue.Kind() != reflect.Struct {
+		return errors.New("validate expects a struct, got " + rValue.Kind().String())
+	}
+
+	rType := rValue.Type()
+	validationErrs := ValidationErrors{}
+
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+
+		if !ok || !field.IsExported() || (fields != nil && !slices.Contains(fields, field.Name)) {
+			continue
+		}
+
+		fieldName := toLowerCamelCase(field.Name)
+		message, err := validateField(fieldName, rValue.Field(i), tag)
+
+		if err != nil {
+			return fmt.Errorf("invalid validate tag on %s.%s: %w", rType.Name(), field.Name, err)
+		}
+
+		if message != "" {
+			validationErrs = append(validationErrs, ValidationError{Field: fieldName, Message: message})
+		}
+	}
+
+	if len(validationErrs) > 0 {
+		return validationErrs
+	}
+
+	return nil
+}
+
+// validateField runs the rules of tag in order and returns the message of the first one that
+// fails, or an empty string when they all pass
+func validateField(fieldName string, value reflect.Value, tag string) (string, error) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if hasRule(tag, "required") {
+				return fieldName + " is required", nil
+			}
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "":
+			continue
+		case "required":
+			if isBlank(value) {
+				return fieldName + " is required", nil
+			}
+		case "email":
+			if value.Kind() != reflect.String {
+				return "", errors.New("email only applies to strings")
+			}
+
+			// skip empty values so optional fields can be left blank, required catches them otherwise
+			if value.String() != "" && !isEmail(value.String()) {
+				return fieldName + " must be a valid email address", nil
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(param, 64)
+
+			if err != nil {
+				return "", errors.New(name + " expects a number, got " + strconv.Quote(param))
+			}
+
+			size, unit, err := measure(value)
+
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+
+			if name == "min" && size < limit {
+				return fieldName + " must be at least " + param + unit, nil
+			}
+
+			if name == "max" && size > limit {
+				return fieldName + " must be at most " + param + unit, nil
+			}
+		default:
+			return "", errors.New("unknown rule " + name)
+		}
+	}
+
+	return "", nil
+}
+
+func hasRule(tag string, name string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if strings.TrimSpace(rule) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Map, reflect.Slice, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
+
+func isEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// measure returns the size min and max compare against, with the unit used in messages
+func measure(value reflect.Value) (float64, string, error) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters", nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), " items", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "", nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "", nil
+	}
+	return 0, "", errors.New("cannot measure " + value.Kind().String())
+}
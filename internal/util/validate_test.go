@@ -0,0 +1,120 @@
+package util_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"smithsolutions/go-api/internal/util"
+)
+
+type testValidated struct {
+	Email    string   `validate:"required,email,max=255"`
+	Label    *string  `validate:"required,max=5"`
+	Note     *string  `validate:"max=3"`
+	Count    int      `validate:"min=1,max=10"`
+	Tags     []string `validate:"max=2"`
+	Untagged string
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() testValidated {
+		return testValidated{Email: "a@example.com", Label: util.ToPointer("héllo"), Count: 1}
+	}
+
+	cases := []struct {
+		name   string
+		modify func(data *testValidated)
+		errs   util.ValidationErrors
+	}{
+		{"valid", func(data *testValidated) {}, nil},
+		{"nil optional pointer", func(data *testValidated) { data.Note = nil }, nil},
+		{"blank required", func(data *testValidated) { data.Email = "  " }, util.ValidationErrors{{Field: "email", Message: "email is required"}}},
+		{"bad email", func(data *testValidated) { data.Email = "not an email" }, util.ValidationErrors{{Field: "email", Message: "email must be a valid email address"}}},
+		{"display name email", func(data *testValidated) { data.Email = "A <a@example.com>" }, util.ValidationErrors{{Field: "email", Message: "email must be a valid email address"}}},
+		{"email too long", func(data *testValidated) { data.Email = strings.Repeat("a", 250) + "@example.com" }, util.ValidationErrors{{Field: "email", Message: "email must be at most 255 characters"}}},
+		{"nil required pointer", func(data *testValidated) { data.Label = nil }, util.ValidationErrors{{Field: "label", Message: "label is required"}}},
+		{"characters not bytes", func(data *testValidated) { data.Label = util.ToPointer("héllö") }, nil},
+		{"pointer too long", func(data *testValidated) { data.Note = util.ToPointer("abcd") }, util.ValidationErrors{{Field: "note", Message: "note must be at most 3 characters"}}},
+		{"number too small", func(data *testValidated) { data.Count = 0 }, util.ValidationErrors{{Field: "count", Message: "count must be at least 1"}}},
+		{"too many items", func(data *testValidated) { data.Tags = []string{"a", "b", "c"} }, util.ValidationErrors{{Field: "tags", Message: "tags must be at most 2 items"}}},
+		{"every failed field", func(data *testValidated) { data.Email = ""; data.Count = 11 }, util.ValidationErrors{
+			{Field: "email", Message: "email is required"},
+			{Field: "count", Message: "count must be at most 10"},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := valid()
+			c.modify(&data)
+
+			err := util.Validate(data)
+
+			if c.errs == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs util.ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+
+			if !reflect.DeepEqual(errs, c.errs) {
+				t.Errorf("got %#v, want %#v", errs, c.errs)
+			}
+		})
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	// the missing email and label are not checked since only count and note are named
+	data := testValidated{Count: 0, Note: util.ToPointer("abcd")}
+
+	err := util.ValidateFields(data, []string{"Count", "Note"})
+
+	want := util.ValidationErrors{
+		{Field: "note", Message: "note must be at most 3 characters"},
+		{Field: "count", Message: "count must be at least 1"},
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+
+	if err := util.ValidateFields(data, []string{}); err != nil {
+		t.Errorf("expected no fields to pass, got %v", err)
+	}
+}
+
+func TestValidateMalformedTags(t *testing.T) {
+	cases := []struct {
+		name string
+		data any
+	}{
+		{"unknown rule", struct {
+			Name string `validate:"bogus"`
+		}{}},
+		{"max without number", struct {
+			Name string `validate:"max=x"`
+		}{}},
+		{"email on a number", struct {
+			Count int `validate:"email"`
+		}{}},
+		{"not a struct", "value"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := util.Validate(c.data)
+
+			var errs util.ValidationErrors
+			if err == nil || errors.As(err, &errs) {
+				t.Errorf("expected a plain error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"reflect"
+	"sync"
+)
+
+// WhereRelation links a relation filter field on a where struct to the related table
+type WhereRelation interface {
+	// ExistsSQL returns an EXISTS subquery matching related rows that satisfy where,
+	// where may be empty to match any related row
+	ExistsSQL(where string) string
+	// ModelField is the model field the related rows are attached to when loaded
+	ModelField() string
+}
+
+type RelationFilterSQLer interface {
+	RelationSQL(relation WhereRelation) (string, []any, error)
+}
+
+type whereRelationKey struct {
+	whereType reflect.Type
+	field     string
+}
+
+var (
+	whereRelations      = make(map[whereRelationKey]WhereRelation)
+	whereRelationsMutex sync.RWMutex
+)
+
+// RegisterWhereRelation makes relation available to the relation filter stored in field of whereType
+func RegisterWhereRelation(whereType reflect.Type, field string, relation WhereRelation) {
+	whereRelationsMutex.Lock()
+	defer whereRelationsMutex.Unlock()
+
+	whereRelations[whereRelationKey{whereType, field}] = relation
+}
+
+func getWhereRelation(whereType reflect.Type, field string) (WhereRelation, bool) {
+	whereRelationsMutex.RLock()
+	defer whereRelationsMutex.RUnlock()
+
+	relation, ok := whereRelations[whereRelationKey{whereType, field}]
+	return relation, ok
+}